@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/compare"
+	"github.com/xhos/fxgo/internal/config"
+)
+
+func runCompare(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	sources := fs.String("sources", "", "comma-separated sources to compare (default all)")
+	from := fs.String("from", "", "first date, YYYY-MM-DD (default 30 days ago)")
+	to := fs.String("to", "", "last date, YYYY-MM-DD (default today)")
+	threshold := fs.Float64("threshold", compare.DefaultThreshold, "spread above which a day is flagged, as a fraction")
+	all := fs.Bool("all", false, "list every compared day, not only flagged ones")
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start, err := parseDateFlag(*from, today.Add(-defaultGapWindow))
+	if err != nil {
+		return fmt.Errorf("parsing --from: %w", err)
+	}
+	end, err := parseDateFlag(*to, today)
+	if err != nil {
+		return fmt.Errorf("parsing --to: %w", err)
+	}
+
+	opts := compare.Options{Threshold: *threshold}
+	if *sources != "" {
+		opts.Sources = strings.Split(*sources, ",")
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	report, err := compare.Compare(ctx, database, start, end, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d pairs compared from %s to %s, threshold %.2f%%\n",
+		len(report.Pairs), start.Format("2006-01-02"), end.Format("2006-01-02"), report.Threshold*100)
+	for _, s := range report.Pairs {
+		fmt.Printf("  %s/%s\t%s\tdays %d\tflagged %d\tmean %.3f%%\tmax %.3f%%\n",
+			s.Base, s.Target, strings.Join(s.Sources, ","), s.Days, s.Flagged, s.MeanSpread*100, s.MaxSpread*100)
+	}
+
+	// derived quotes are marked with *, flagged days with !
+	for _, row := range report.Rows {
+		if !row.Flagged && !*all {
+			continue
+		}
+
+		var quotes []string
+		for _, q := range row.Quotes {
+			marker := ""
+			if q.Derived {
+				marker = "*"
+			}
+			quotes = append(quotes, fmt.Sprintf("%s%s=%.6g", q.Source, marker, q.Value))
+		}
+
+		mark := " "
+		if row.Flagged {
+			mark = "!"
+		}
+		fmt.Printf("%s %s %s/%s\t%.3f%%\t%s\n",
+			mark, row.Date.Format("2006-01-02"), row.Base, row.Target, row.Spread*100, strings.Join(quotes, " "))
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/xhos/fxgo/internal/config"
+)
+
+// fxgo currencies [--stored] [--refresh] [--format table|csv|json]
+func runCurrencies(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("currencies", flag.ContinueOnError)
+	storedOnly := fs.Bool("stored", false, "only list currencies with stored rates")
+	refresh := fs.Bool("refresh", false, "rediscover the providers' currency catalogues first")
+	format := formatFlag(fs)
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected argument %q", positional[0])
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	targets, err := database.GetAvailableCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+	bases, err := database.GetAvailableBases(ctx)
+	if err != nil {
+		return err
+	}
+	stored := append(targets, bases...)
+
+	providers, err := enabledProviders(cfg)
+	if err != nil {
+		return err
+	}
+	if err := loadCatalogues(ctx, database, providers, *refresh); err != nil {
+		return err
+	}
+
+	// which enabled providers publish each currency, bases included
+	sources := make(map[string][]string)
+	for _, p := range providers {
+		for _, currency := range append([]string{p.Base()}, p.SupportedCurrencies()...) {
+			sources[currency] = append(sources[currency], p.Name())
+		}
+	}
+
+	currencies := slices.Clone(stored)
+	for currency := range sources {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+	currencies = slices.Compact(currencies)
+
+	var rows [][]any
+	for _, currency := range currencies {
+		isStored := slices.Contains(stored, currency)
+		if *storedOnly && !isStored {
+			continue
+		}
+		rows = append(rows, []any{currency, sources[currency], isStored})
+	}
+
+	return writeRows(os.Stdout, *format, []string{"currency", "sources", "stored"}, rows)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/coverage"
+)
+
+const defaultGapWindow = 30 * 24 * time.Hour
+
+func runGaps(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("gaps", flag.ContinueOnError)
+	source := fs.String("source", "", "source to check, e.g. ECB")
+	from := fs.String("from", "", "first date to check, YYYY-MM-DD (default 30 days ago)")
+	to := fs.String("to", "", "last date to check, YYYY-MM-DD (default yesterday)")
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+	heal := fs.Bool("heal", false, "re-fetch the missing dates from the source")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *source == "" {
+		return fmt.Errorf("--source is required")
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start, err := parseDateFlag(*from, today.Add(-defaultGapWindow))
+	if err != nil {
+		return fmt.Errorf("parsing --from: %w", err)
+	}
+	// today's rates are rarely published yet, so they'd always show up missing
+	end, err := parseDateFlag(*to, today.AddDate(0, 0, -1))
+	if err != nil {
+		return fmt.Errorf("parsing --to: %w", err)
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	report, err := coverage.Check(ctx, database, *source, start, end)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d business days from %s to %s, %d with gaps\n",
+		report.Source, report.Expected, start.Format("2006-01-02"), end.Format("2006-01-02"), len(report.Gaps))
+	for _, gap := range report.Gaps {
+		fmt.Println(" ", gap)
+	}
+
+	nothingToHeal := (!*heal || len(report.Gaps) == 0)
+	if nothingToHeal {
+		return nil
+	}
+
+	p, err := providerByName(cfg, *source)
+	if err != nil {
+		return err
+	}
+
+	result := coverage.Heal(ctx, database, p, report)
+	fmt.Printf("healed %d rates, %d quarantined, %d still missing, %d gaps failed\n",
+		result.Healed, result.Quarantined, len(result.Missing), len(result.Failed))
+	for _, missing := range result.Missing {
+		fmt.Println(" ", missing)
+	}
+	for _, err := range result.Failed {
+		fmt.Println(" ", err)
+	}
+
+	return nil
+}
+
+func parseDateFlag(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
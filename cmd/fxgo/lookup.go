@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/calendar"
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/readthrough"
+)
+
+var rateColumns = []string{"date", "base", "target", "rate", "source", "calculated", "note"}
+
+// fxgo rate EUR USD [--date 2024-03-01] [--fetch] [--format table|csv|json]
+func runRate(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("rate", flag.ContinueOnError)
+	date := fs.String("date", "", "date of the rate, YYYY-MM-DD (default latest)")
+	fetch := fs.Bool("fetch", false, "ask the providers when the date isn't stored")
+	format := formatFlag(fs)
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("expected a base and a target currency, e.g. fxgo rate EUR USD")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	base, target := currencyArg(positional[0]), currencyArg(positional[1])
+	day, err := parseDateFlag(*date, time.Time{})
+	if err != nil {
+		return fmt.Errorf("parsing --date: %w", err)
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := lookupRate(ctx, cfg, database, base, target, day, *fetch)
+	if err != nil {
+		return err
+	}
+
+	return writeRows(os.Stdout, *format, rateColumns, [][]any{rateRow(result.Rate, result.Reason)})
+}
+
+// fxgo convert 125.40 CAD EUR [--date 2024-03-01] [--fetch] [--format table|csv|json]
+func runConvert(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	date := fs.String("date", "", "date of the rate, YYYY-MM-DD (default latest)")
+	fetch := fs.Bool("fetch", false, "ask the providers when the date isn't stored")
+	precision := fs.Int("precision", 2, "decimals of the converted amount")
+	format := formatFlag(fs)
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 3 {
+		return fmt.Errorf("expected an amount and two currencies, e.g. fxgo convert 125.40 CAD EUR")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	amount, err := strconv.ParseFloat(positional[0], 64)
+	if err != nil {
+		return fmt.Errorf("parsing amount %q: %w", positional[0], err)
+	}
+	from, to := currencyArg(positional[1]), currencyArg(positional[2])
+
+	day, err := parseDateFlag(*date, time.Time{})
+	if err != nil {
+		return fmt.Errorf("parsing --date: %w", err)
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	result, err := lookupRate(ctx, cfg, database, from, to, day, *fetch)
+	if err != nil {
+		return err
+	}
+
+	rate := result.Rate
+	columns := []string{"date", "amount", "from", "to", "rate", "converted", "source", "note"}
+	row := []any{rate.Date, amount, from, to, rate.Value, number{amount * rate.Value, *precision}, rate.Source, result.Reason}
+
+	return writeRows(os.Stdout, *format, columns, [][]any{row})
+}
+
+// fxgo history USD JPY [--from 2024-01-01] [--to 2024-03-31] [--fetch] [--format table|csv|json]
+func runHistory(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	from := fs.String("from", "", "first date, YYYY-MM-DD (default 30 days ago)")
+	to := fs.String("to", "", "last date, YYYY-MM-DD (default yesterday)")
+	fetch := fs.Bool("fetch", false, "ask the providers for weekdays that aren't stored")
+	format := formatFlag(fs)
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return fmt.Errorf("expected a base and a target currency, e.g. fxgo history USD JPY")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+
+	base, target := currencyArg(positional[0]), currencyArg(positional[1])
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	start, err := parseDateFlag(*from, today.Add(-defaultGapWindow))
+	if err != nil {
+		return fmt.Errorf("parsing --from: %w", err)
+	}
+	// today's rates are rarely published yet, --fetch would ask for them in vain
+	end, err := parseDateFlag(*to, today.AddDate(0, 0, -1))
+	if err != nil {
+		return fmt.Errorf("parsing --to: %w", err)
+	}
+	if end.Before(start) {
+		return fmt.Errorf("--to is before --from")
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	rates, err := database.GetRatesBetween(ctx, start, end, base, []string{target})
+	if err != nil {
+		return err
+	}
+
+	if *fetch {
+		reader, err := newReader(ctx, cfg, database)
+		if err != nil {
+			return err
+		}
+
+		fetchedAny := false
+		for _, day := range missingWeekdays(rates, start, end) {
+			rate, err := reader.GetRate(ctx, day, base, target)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "fetching %s: %v\n", day.Format("2006-01-02"), err)
+				continue
+			}
+			fetchedAny = fetchedAny || rate != nil
+		}
+
+		if fetchedAny {
+			rates, err = database.GetRatesBetween(ctx, start, end, base, []string{target})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var rows [][]any
+	for _, rate := range rates {
+		rows = append(rows, rateRow(rate, ""))
+	}
+
+	return writeRows(os.Stdout, *format, rateColumns, rows)
+}
+
+// lookupRate finds the pair's rate for the date, or the latest one for a zero
+// date. Weekends and holidays fall back to the previous publication. With
+// fetch, a date the store lacks is asked from the providers first.
+func lookupRate(ctx context.Context, cfg config.Config, database db.Store, base, target string, date time.Time, fetch bool) (*db.AsOfResult, error) {
+	store := database
+	if fetch {
+		reader, err := newReader(ctx, cfg, database)
+		if err != nil {
+			return nil, err
+		}
+		store = reader
+	}
+
+	hasDate := !date.IsZero()
+	if hasDate {
+		result, err := store.GetRateAsOf(ctx, base, target, date, db.AsOfOptions{})
+		return found(result, base, target, date, fetch, err)
+	}
+
+	rate, err := store.GetLatestRate(ctx, base, target)
+	if rate == nil {
+		return found(nil, base, target, date, fetch, err)
+	}
+	return &db.AsOfResult{Rate: *rate, RequestedDate: rate.Date, EffectiveDate: rate.Date}, nil
+}
+
+// newReader reads through to the enabled providers in priority order, with
+// their currency catalogues loaded so only currencies they still publish are asked for
+func newReader(ctx context.Context, cfg config.Config, database db.Store) (*readthrough.Reader, error) {
+	providers, err := enabledProviders(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadCatalogues(ctx, database, providers, false); err != nil {
+		return nil, err
+	}
+	return readthrough.New(database, providers), nil
+}
+
+// found turns a missing rate into an error naming the pair and, if the
+// providers were asked, why they couldn't help
+func found(result *db.AsOfResult, base, target string, date time.Time, fetched bool, err error) (*db.AsOfResult, error) {
+	if result != nil {
+		return result, nil
+	}
+
+	day := "any date"
+	if !date.IsZero() {
+		day = date.Format("2006-01-02")
+	}
+
+	switch {
+	case err != nil && fetched:
+		return nil, fmt.Errorf("no %s/%s rate for %s, fetching failed: %w", base, target, day, err)
+	case err != nil:
+		return nil, err
+	case fetched:
+		return nil, fmt.Errorf("no provider publishes %s/%s for %s", base, target, day)
+	}
+	return nil, fmt.Errorf("no %s/%s rate stored for %s, --fetch asks the providers", base, target, day)
+}
+
+func rateRow(rate models.Rate, note string) []any {
+	return []any{rate.Date, rate.Base, rate.Target, rate.Value, rate.Source, rate.Calculated, note}
+}
+
+// missingWeekdays lists the weekdays between start and end without a rate
+func missingWeekdays(rates []models.Rate, start, end time.Time) []time.Time {
+	stored := make(map[string]bool, len(rates))
+	for _, rate := range rates {
+		stored[rate.Date.Format("2006-01-02")] = true
+	}
+
+	var missing []time.Time
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		isWeekday := calendar.ExpectsData("", day)
+		if isWeekday && !stored[day.Format("2006-01-02")] {
+			missing = append(missing, day)
+		}
+	}
+	return missing
+}
+
+// parseArgs lets flags follow the positional arguments, "fxgo rate EUR USD --date ..."
+// reads better than putting the flags first
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func currencyArg(arg string) string {
+	return strings.ToUpper(strings.TrimSpace(arg))
+}
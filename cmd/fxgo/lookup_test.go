@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("rate", flag.ContinueOnError)
+	date := fs.String("date", "", "")
+	format := fs.String("format", formatTable, "")
+
+	positional, err := parseArgs(fs, []string{"eur", "--date", "2024-03-01", "usd", "--format=json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correct := slices.Equal(positional, []string{"eur", "usd"}) && *date == "2024-03-01" && *format == formatJSON
+	if !correct {
+		t.Errorf("got %v, --date %q, --format %q", positional, *date, *format)
+	}
+
+	if _, err := parseArgs(fs, []string{"eur", "--precision", "2"}); err == nil {
+		t.Error("expected an error for an unknown flag")
+	}
+}
+
+func TestMissingWeekdays(t *testing.T) {
+	// thursday to the following tuesday, friday is stored
+	start := time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 10, 21, 0, 0, 0, 0, time.UTC)
+	rates := []models.Rate{{Date: time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)}}
+
+	var got []string
+	for _, day := range missingWeekdays(rates, start, end) {
+		got = append(got, day.Format("2006-01-02"))
+	}
+
+	want := []string{"2025-10-16", "2025-10-20", "2025-10-21"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLookupRate(t *testing.T) {
+	ctx := context.Background()
+	friday := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+
+	database := db.NewMemory()
+	err := database.InsertRate(ctx, models.Rate{Date: friday, Base: "EUR", Target: "USD", Value: 1.1681, Source: "ECB", Fetched: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("weekend falls back with a note", func(t *testing.T) {
+		result, err := lookupRate(ctx, config.Default(), database, "EUR", "USD", saturday, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correct := result.Rate.Date.Equal(friday) && result.Rate.Value == 1.1681 && strings.Contains(result.Reason, "Saturday")
+		if !correct {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("exact date has no note", func(t *testing.T) {
+		result, err := lookupRate(ctx, config.Default(), database, "EUR", "USD", friday, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Reason != "" {
+			t.Errorf("unexpected note: %q", result.Reason)
+		}
+	})
+
+	t.Run("missing pair suggests --fetch", func(t *testing.T) {
+		_, err := lookupRate(ctx, config.Default(), database, "EUR", "JPY", saturday, false)
+		if err == nil || !strings.Contains(err.Error(), "--fetch") {
+			t.Errorf("expected an error suggesting --fetch, got %v", err)
+		}
+	})
+
+	t.Run("latest cross through the base", func(t *testing.T) {
+		crosses := db.NewMemory()
+		crosses.SetSourcePriority([]string{"ECB", "BIS"})
+
+		rates := []models.Rate{
+			{Date: friday, Base: "EUR", Target: "USD", Value: 1.1681, Source: "ECB", Fetched: time.Now()},
+			{Date: friday, Base: "EUR", Target: "PLN", Value: 4.2435, Source: "ECB", Fetched: time.Now()},
+			{Date: friday, Base: "EUR", Target: "USD", Value: 1.17, Source: "BIS", Fetched: time.Now()},
+		}
+		for _, rate := range rates {
+			if err := crosses.InsertRate(ctx, rate); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// derived through EUR, with ECB's EUR/USD ahead of BIS's
+		result, err := lookupRate(ctx, config.Default(), crosses, "PLN", "USD", time.Time{}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := 1.1681 / 4.2435
+		correct := result.Rate.Date.Equal(friday) && math.Abs(result.Rate.Value-want) < 1e-12 && result.Rate.Calculated
+		if !correct {
+			t.Errorf("got %+v, want PLN/USD %v from ECB", result.Rate, want)
+		}
+	})
+}
@@ -0,0 +1,118 @@
+// Command fxgo maintains the local rate database
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, cfg config.Config, args []string) error
+}
+
+var commands = []command{
+	{"rate", "look up a pair's rate for a date, or the latest one", runRate},
+	{"convert", "convert an amount between two currencies", runConvert},
+	{"history", "list a pair's rates over a date range", runHistory},
+	{"currencies", "list the currencies the enabled providers publish and the store holds", runCurrencies},
+	{"gaps", "report missing business days for a source, optionally re-fetching them", runGaps},
+	{"quarantine", "list suspicious rates held back from storage, approve or reject them", runQuarantine},
+	{"compare", "line up pairs quoted by several sources and flag days they diverge", runCompare},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	configPath := flag.String("config", "", "path to the config file (default $FXGO_CONFIG or ./fxgo.yaml)")
+	flag.Usage = usage
+	flag.Parse()
+
+	noCommand := (flag.NArg() < 1)
+	if noCommand {
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "fxgo: %v\n", err)
+		os.Exit(1)
+	}
+
+	name, args := flag.Arg(0), flag.Args()[1:]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		if err := cmd.run(ctx, cfg, args); err != nil {
+			fmt.Fprintf(os.Stderr, "fxgo %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "fxgo: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: fxgo [--config file] <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-11s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// loadConfig reads the named file, else $FXGO_CONFIG, else ./fxgo.yaml if
+// there is one, so the defaults need no file at all
+func loadConfig(path string) (config.Config, error) {
+	path = cmp.Or(path, os.Getenv(config.EnvPath))
+
+	if path == "" {
+		_, err := os.Stat(config.DefaultPath)
+		hasDefaultFile := !errors.Is(err, fs.ErrNotExist)
+		if hasDefaultFile {
+			path = config.DefaultPath
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return config.Config{}, err
+	}
+
+	if err := checkProviders(cfg); err != nil {
+		return config.Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// openDatabase opens the configured database, or path when it's set, preferring
+// rates from the enabled providers in their configured order
+func openDatabase(cfg config.Config, path string) (*db.DB, error) {
+	database, err := db.Open(cmp.Or(path, cfg.Database.Path),
+		db.WithJournalMode(cfg.Database.JournalMode),
+		db.WithBusyTimeout(cfg.Database.BusyTimeout),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	database.SetSourcePriority(enabledNames(cfg))
+	return database, nil
+}
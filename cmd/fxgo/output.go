@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// output formats of the lookup commands
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+var formats = []string{formatTable, formatCSV, formatJSON}
+
+func formatFlag(fs *flag.FlagSet) *string {
+	return fs.String("format", formatTable, "output format: table, csv or json")
+}
+
+func checkFormat(format string) error {
+	if !slices.Contains(formats, format) {
+		return fmt.Errorf("unknown format %q, expected one of %v", format, formats)
+	}
+	return nil
+}
+
+// number keeps a value numeric in json while fixing its decimals elsewhere,
+// a negative precision prints the shortest exact form
+type number struct {
+	value     float64
+	precision int
+}
+
+// writeRows prints one row per record, json rows become objects keyed by column
+func writeRows(w io.Writer, format string, columns []string, rows [][]any) error {
+	switch format {
+	case formatCSV:
+		return writeCSV(w, columns, rows)
+	case formatJSON:
+		return writeJSON(w, columns, rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, text(cell))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, columns []string, rows [][]any) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		var cells []string
+		for _, cell := range row {
+			cells = append(cells, text(cell))
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeJSON keeps the column order, which a map would lose
+func writeJSON(w io.Writer, columns []string, rows [][]any) error {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  {")
+
+		for j, cell := range row {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+
+			key, _ := json.Marshal(columns[j])
+			value, err := json.Marshal(jsonValue(cell))
+			if err != nil {
+				return fmt.Errorf("encoding %s: %w", columns[j], err)
+			}
+			buf.Write(key)
+			buf.WriteString(": ")
+			buf.Write(value)
+		}
+
+		buf.WriteString("}")
+	}
+
+	if len(rows) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func text(cell any) string {
+	switch v := cell.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format("2006-01-02")
+	case number:
+		return strconv.FormatFloat(v.value, 'f', v.precision, 64)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return fmt.Sprint(cell)
+}
+
+func jsonValue(cell any) any {
+	switch v := cell.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return nil
+		}
+		return v.Format("2006-01-02")
+	case number:
+		// round through the text form so json shows the same digits
+		rounded, _ := strconv.ParseFloat(text(v), 64)
+		return rounded
+	}
+	return cell
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+var (
+	testColumns = []string{"date", "currency", "rate", "sources", "stored"}
+	testRows    = [][]any{
+		{time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC), "USD", 1.1681, []string{"ECB", "BIS"}, true},
+		{time.Time{}, `say "hi", then`, number{125.4 * 0.6016, 2}, []string(nil), false},
+	}
+)
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, testColumns, testRows); err != nil {
+		t.Fatal(err)
+	}
+
+	// columns keep their order, zero dates are null and numbers keep their precision
+	want := `[
+  {"date": "2025-10-17", "currency": "USD", "rate": 1.1681, "sources": ["ECB","BIS"], "stored": true},
+  {"date": null, "currency": "say \"hi\", then", "rate": 75.44, "sources": null, "stored": false}
+]
+`
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, testColumns, nil); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("got %q for no rows, want an empty array", buf.String())
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, testColumns, testRows); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "date,currency,rate,sources,stored\n" +
+		"2025-10-17,USD,1.1681,\"ECB,BIS\",true\n" +
+		",\"say \"\"hi\"\", then\",75.44,,false\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		value     number
+		text      string
+		jsonValue float64
+	}{
+		{number{75.44064, 2}, "75.44", 75.44},
+		{number{2.675, 2}, "2.67", 2.67}, // 2.675 is stored just below, so it rounds down
+		{number{0.5, 0}, "0", 0},         // half to even
+		{number{1.5, 0}, "2", 2},
+		{number{1.1681, -1}, "1.1681", 1.1681},
+	}
+
+	for _, tt := range tests {
+		if got := text(tt.value); got != tt.text {
+			t.Errorf("text(%v) = %q, want %q", tt.value, got, tt.text)
+		}
+		if got := jsonValue(tt.value); got != tt.jsonValue {
+			t.Errorf("jsonValue(%v) = %v, want %v", tt.value, got, tt.jsonValue)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/catalog"
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/bankofcanada"
+	"github.com/xhos/fxgo/internal/provider/bis"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/ecb"
+	"github.com/xhos/fxgo/internal/provider/imf"
+	"github.com/xhos/fxgo/internal/provider/mufg"
+)
+
+type registeredProvider struct {
+	name string
+	// priority in the fallback chain unless configured, lower first
+	priority int
+	timeout  time.Duration
+	build    func(settings config.Provider, client *common.HTTPClient) (provider.Provider, error)
+}
+
+// central banks come before the aggregators, which mostly fill in the rest
+var providers = []registeredProvider{
+	{"ECB", 10, 30 * time.Second, buildECB},
+	{"BankOfCanada", 20, 30 * time.Second, buildBankOfCanada},
+	{"MUFG", 30, 30 * time.Second, buildMUFG},
+	{"BIS", 40, 60 * time.Second, buildBIS},
+	{"IMF", 50, 30 * time.Second, buildIMF},
+}
+
+func buildECB(settings config.Provider, client *common.HTTPClient) (provider.Provider, error) {
+	opts := []ecb.Option{ecb.WithHTTPClient(client)}
+	if settings.BaseURL != "" {
+		opts = append(opts, ecb.WithBaseURL(settings.BaseURL))
+	}
+
+	feeds := []ecb.Feed{ecb.FeedDataAPI, ecb.FeedDaily, ecb.FeedHist90d, ecb.FeedHist}
+	for option, value := range settings.Options {
+		switch option {
+		case "feed":
+			feed := ecb.Feed(value)
+			if !slices.Contains(feeds, feed) {
+				return nil, fmt.Errorf("options.feed: %q is not one of %v", value, feeds)
+			}
+			opts = append(opts, ecb.WithFeed(feed))
+		case "reference_base_url":
+			opts = append(opts, ecb.WithReferenceBaseURL(value))
+		default:
+			return nil, fmt.Errorf("options.%s: unknown option, expected feed or reference_base_url", option)
+		}
+	}
+
+	return ecb.New(opts...), nil
+}
+
+func buildBankOfCanada(settings config.Provider, client *common.HTTPClient) (provider.Provider, error) {
+	if err := noOptions(settings); err != nil {
+		return nil, err
+	}
+
+	opts := []bankofcanada.Option{bankofcanada.WithHTTPClient(client)}
+	if settings.BaseURL != "" {
+		opts = append(opts, bankofcanada.WithBaseURL(settings.BaseURL))
+	}
+	return bankofcanada.New(opts...), nil
+}
+
+func buildMUFG(settings config.Provider, client *common.HTTPClient) (provider.Provider, error) {
+	if err := noOptions(settings); err != nil {
+		return nil, err
+	}
+
+	opts := []mufg.Option{mufg.WithHTTPClient(client)}
+	if settings.BaseURL != "" {
+		opts = append(opts, mufg.WithBaseURL(settings.BaseURL))
+	}
+	return mufg.New(opts...), nil
+}
+
+func buildBIS(settings config.Provider, client *common.HTTPClient) (provider.Provider, error) {
+	if err := noOptions(settings); err != nil {
+		return nil, err
+	}
+
+	opts := []bis.Option{bis.WithHTTPClient(client)}
+	if settings.BaseURL != "" {
+		opts = append(opts, bis.WithBaseURL(settings.BaseURL))
+	}
+	return bis.New(opts...), nil
+}
+
+func buildIMF(settings config.Provider, client *common.HTTPClient) (provider.Provider, error) {
+	if err := noOptions(settings); err != nil {
+		return nil, err
+	}
+
+	opts := []imf.Option{imf.WithHTTPClient(client)}
+	if settings.BaseURL != "" {
+		opts = append(opts, imf.WithBaseURL(settings.BaseURL))
+	}
+	return imf.New(opts...), nil
+}
+
+func noOptions(settings config.Provider) error {
+	for option := range settings.Options {
+		return fmt.Errorf("options.%s: this provider takes no options", option)
+	}
+	return nil
+}
+
+func (r registeredProvider) newProvider(cfg config.Config) (provider.Provider, error) {
+	settings := cfg.Provider(r.name)
+	timeout := cmp.Or(settings.Timeout, cfg.HTTP.Timeout, r.timeout)
+
+	p, err := r.build(settings, cfg.HTTP.NewClient(timeout))
+	if err != nil {
+		return nil, fmt.Errorf("providers.%s.%w", r.name, err)
+	}
+	return p, nil
+}
+
+func providerNames() []string {
+	var names []string
+	for _, r := range providers {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+func providerByName(cfg config.Config, name string) (provider.Provider, error) {
+	for _, r := range providers {
+		if strings.EqualFold(r.name, name) {
+			return r.newProvider(cfg)
+		}
+	}
+	return nil, fmt.Errorf("unknown source %q, expected one of %v", name, providerNames())
+}
+
+// enabledProviders builds the configured fallback chain, lowest priority first
+func enabledProviders(cfg config.Config) ([]provider.Provider, error) {
+	var enabled []provider.Provider
+	for _, r := range enabledRegistered(cfg) {
+		p, err := r.newProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		enabled = append(enabled, p)
+	}
+	return enabled, nil
+}
+
+// loadCatalogues swaps the static currency lists of the providers that can
+// discover theirs for the catalogue cached in the database, which is refreshed
+// once a week. Outside a forced refresh a failed discovery only warns, the
+// static list still works.
+func loadCatalogues(ctx context.Context, database db.Store, providers []provider.Provider, refresh bool) error {
+	c := catalog.New(database, catalog.DefaultRefreshInterval, catalog.DefaultStaleAfter)
+
+	for _, p := range providers {
+		discoverer, canDiscover := p.(provider.Discoverer)
+		if !canDiscover {
+			continue
+		}
+
+		if refresh {
+			if _, err := c.Refresh(ctx, discoverer); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := c.Currencies(ctx, discoverer); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: using the built-in %s currencies: %v\n", p.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+// enabledNames is the fallback chain by name, without building the providers
+func enabledNames(cfg config.Config) []string {
+	var names []string
+	for _, r := range enabledRegistered(cfg) {
+		names = append(names, r.name)
+	}
+	return names
+}
+
+func enabledRegistered(cfg config.Config) []registeredProvider {
+	ordered := slices.Clone(providers)
+	priority := func(r registeredProvider) int {
+		return cmp.Or(cfg.Provider(r.name).Priority, r.priority)
+	}
+	slices.SortStableFunc(ordered, func(a, b registeredProvider) int {
+		return cmp.Compare(priority(a), priority(b))
+	})
+
+	return slices.DeleteFunc(ordered, func(r registeredProvider) bool {
+		return !cfg.Provider(r.name).IsEnabled()
+	})
+}
+
+// checkProviders catches settings for providers that don't exist and options
+// they don't understand, so a bad config fails at startup
+func checkProviders(cfg config.Config) error {
+	for name := range cfg.Providers {
+		isKnown := slices.ContainsFunc(providers, func(r registeredProvider) bool {
+			return strings.EqualFold(r.name, name)
+		})
+		if !isKnown {
+			return fmt.Errorf("providers.%s: unknown provider, expected one of %v", name, providerNames())
+		}
+	}
+
+	for _, r := range providers {
+		if _, err := r.newProvider(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+)
+
+func TestEnabledNames(t *testing.T) {
+	cfg := config.Default()
+	if got := enabledNames(cfg); !slices.Equal(got, providerNames()) {
+		t.Errorf("got %v, want the registry order %v", got, providerNames())
+	}
+
+	disabled := false
+	cfg.Providers = map[string]config.Provider{
+		"IMF": {Priority: 1},
+		"BIS": {Enabled: &disabled},
+	}
+
+	want := []string{"IMF", "ECB", "BankOfCanada", "MUFG"}
+	if got := enabledNames(cfg); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// discoverer stands in for a provider with a live catalogue
+type discoverer struct {
+	provider.Provider
+	infos     []models.CurrencyInfo
+	err       error
+	supported []string
+}
+
+func (d *discoverer) Name() string { return "ECB" }
+
+func (d *discoverer) DiscoverCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+	return d.infos, d.err
+}
+
+func (d *discoverer) SetSupportedCurrencies(currencies []string) {
+	d.supported = currencies
+}
+
+func TestLoadCatalogues(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("discovered currencies replace the static list", func(t *testing.T) {
+		d := &discoverer{infos: []models.CurrencyInfo{
+			{Currency: "USD", LastDate: now},
+			{Currency: "RUB", LastDate: now.AddDate(-3, 0, 0)},
+		}}
+
+		if err := loadCatalogues(ctx, db.NewMemory(), []provider.Provider{d}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		// RUB stopped updating, so it isn't asked for anymore
+		if !slices.Equal(d.supported, []string{"USD"}) {
+			t.Errorf("got %v, want [USD]", d.supported)
+		}
+	})
+
+	t.Run("a failed discovery only fails a refresh", func(t *testing.T) {
+		d := &discoverer{err: errors.New("unreachable")}
+
+		if err := loadCatalogues(ctx, db.NewMemory(), []provider.Provider{d}, false); err != nil {
+			t.Errorf("expected the static list to be kept, got %v", err)
+		}
+		if d.supported != nil {
+			t.Errorf("static list replaced with %v", d.supported)
+		}
+
+		if err := loadCatalogues(ctx, db.NewMemory(), []provider.Provider{d}, true); err == nil {
+			t.Error("expected --refresh to fail")
+		}
+	})
+}
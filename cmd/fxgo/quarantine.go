@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/xhos/fxgo/internal/config"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// fxgo quarantine [--status pending|approved|rejected|all]
+// fxgo quarantine approve <id>...
+// fxgo quarantine reject <id>...
+func runQuarantine(ctx context.Context, cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("quarantine", flag.ContinueOnError)
+	status := fs.String("status", models.QuarantinePending, "review state to list, or all")
+	dbPath := fs.String("db", "", "path to the database (default database.path)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	database, err := openDatabase(cfg, *dbPath)
+	if err != nil {
+		return err
+	}
+	defer database.Close()
+
+	isList := (fs.NArg() == 0)
+	if isList {
+		return listQuarantine(ctx, database, *status)
+	}
+
+	action, ids := fs.Arg(0), fs.Args()[1:]
+
+	review := map[string]func(context.Context, int64) error{
+		"approve": database.ApproveQuarantined,
+		"reject":  database.RejectQuarantined,
+	}[action]
+
+	if review == nil {
+		return fmt.Errorf("unknown action %q, expected approve or reject", action)
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("%s needs at least one id", action)
+	}
+
+	for _, arg := range ids {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("parsing id %q: %w", arg, err)
+		}
+		if err := review(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("%sd %d\n", action, id)
+	}
+
+	return nil
+}
+
+func listQuarantine(ctx context.Context, database db.Store, status string) error {
+	if status == "all" {
+		status = ""
+	}
+
+	quarantined, err := database.GetQuarantined(ctx, status)
+	if err != nil {
+		return err
+	}
+
+	for _, q := range quarantined {
+		r := q.Rate
+		fmt.Printf("%d\t%s\t%s/%s\t%g\t%s\t%s\t%s\n",
+			q.ID, r.Date.Format("2006-01-02"), r.Base, r.Target, r.Value, r.Source, q.Status, q.Reason)
+	}
+
+	return nil
+}
@@ -3,7 +3,12 @@ module github.com/xhos/fxgo
 go 1.25.1
 
 require (
-	github.com/ncruces/go-sqlite3 v0.29.1 // indirect
+	github.com/lib/pq v1.12.3
+	github.com/ncruces/go-sqlite3 v0.29.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/ncruces/julianday v1.0.0 // indirect
 	github.com/tetratelabs/wazero v1.9.0 // indirect
 	golang.org/x/sys v0.36.0 // indirect
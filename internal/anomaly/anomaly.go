@@ -0,0 +1,257 @@
+// Package anomaly screens incoming rates against the pair's recent history
+// and other sources before they are stored, so a decimal shift or an inverted
+// quote from an upstream format change ends up in quarantine instead of the ledger.
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// Config holds the thresholds, a zero threshold disables its check
+type Config struct {
+	// Window is how many previous observations of the pair are compared against
+	Window int
+	// MaxSigma flags log changes beyond this many standard deviations of the window
+	MaxSigma float64
+	// MinChange ignores sigma breaches smaller than this fraction, so
+	// near-constant pegged series don't trip on noise
+	MinChange float64
+	// MaxChange flags any change from the previous observation beyond this fraction
+	MaxChange float64
+	// MaxSourceDeviation flags rates further than this fraction from the
+	// median of other sources on the same day
+	MaxSourceDeviation float64
+}
+
+var DefaultConfig = Config{
+	Window:             30,
+	MaxSigma:           8,
+	MinChange:          0.02,
+	MaxChange:          0.15,
+	MaxSourceDeviation: 0.05,
+}
+
+// the sigma check needs a few changes before the deviation means anything
+const minSigmaSamples = 5
+
+type Detector struct {
+	db     db.Store
+	config Config
+}
+
+type Result struct {
+	Inserted    int
+	Quarantined int
+}
+
+func New(database db.Store, config Config) *Detector {
+	return &Detector{db: database, config: config}
+}
+
+// Store inserts plausible rates and quarantines the rest. Calculated rates are
+// skipped, they are derived on read.
+func (d *Detector) Store(ctx context.Context, rates []models.Rate) (Result, error) {
+	type pair struct{ base, target, source string }
+	groups := make(map[pair][]models.Rate)
+	var pairs []pair
+
+	for _, rate := range rates {
+		if rate.Calculated {
+			continue
+		}
+
+		p := pair{rate.Base, rate.Target, rate.Source}
+		if groups[p] == nil {
+			pairs = append(pairs, p)
+		}
+		groups[p] = append(groups[p], rate)
+	}
+
+	peersByDay := make(map[string][]models.Rate)
+	var accepted []models.Rate
+	var result Result
+
+	for _, p := range pairs {
+		group := groups[p]
+		slices.SortFunc(group, func(a, b models.Rate) int { return a.Date.Compare(b.Date) })
+
+		history, err := d.db.GetPairHistory(ctx, p.base, p.target, p.source, group[0].Date, d.config.Window)
+		if err != nil {
+			return result, fmt.Errorf("loading history: %w", err)
+		}
+
+		for _, rate := range group {
+			key := rate.Date.Format("2006-01-02")
+			peers, ok := peersByDay[key]
+			if !ok {
+				peers, err = d.db.GetDirectRatesForDate(ctx, rate.Date)
+				if err != nil {
+					return result, fmt.Errorf("loading other sources: %w", err)
+				}
+				peersByDay[key] = peers
+			}
+
+			reason := d.config.Check(rate, history, peers)
+			isSuspicious := (reason != "")
+			if isSuspicious {
+				if err := d.db.QuarantineRate(ctx, rate, reason); err != nil {
+					return result, err
+				}
+				result.Quarantined++
+				continue
+			}
+
+			accepted = append(accepted, rate)
+			history = append(history, rate)
+		}
+	}
+
+	if err := d.db.InsertRates(ctx, accepted); err != nil {
+		return result, err
+	}
+	result.Inserted = len(accepted)
+
+	return result, nil
+}
+
+// Check explains why a rate looks wrong, or returns "" if it is plausible.
+// history is the pair's earlier observations from the same source, oldest
+// first; peers are any stored rates of the same day.
+func (c Config) Check(rate models.Rate, history []models.Rate, peers []models.Rate) string {
+	history = sameFrequency(history, rate.Frequency)
+	if len(history) > c.Window && c.Window > 0 {
+		history = history[len(history)-c.Window:]
+	}
+
+	if reason := c.checkHistory(rate, history); reason != "" {
+		return reason
+	}
+
+	return c.checkPeers(rate, peers)
+}
+
+func (c Config) checkHistory(rate models.Rate, history []models.Rate) string {
+	noHistory := (len(history) == 0)
+	if noHistory {
+		return ""
+	}
+
+	previous := history[len(history)-1]
+	change := rate.Value/previous.Value - 1
+
+	exceedsChange := (c.MaxChange > 0 && math.Abs(change) > c.MaxChange)
+	if exceedsChange {
+		return fmt.Sprintf("change of %+.1f%% since %s exceeds %.1f%%",
+			change*100, previous.Date.Format("2006-01-02"), c.MaxChange*100)
+	}
+
+	var returns []float64
+	for i := 1; i < len(history); i++ {
+		returns = append(returns, math.Log(history[i].Value/history[i-1].Value))
+	}
+
+	tooFewSamples := (c.MaxSigma <= 0 || len(returns) < minSigmaSamples)
+	if tooFewSamples {
+		return ""
+	}
+
+	stddev := standardDeviation(returns)
+	sigmas := math.Abs(math.Log(rate.Value/previous.Value)) / stddev
+
+	exceedsSigma := (stddev > 0 && sigmas > c.MaxSigma && math.Abs(change) > c.MinChange)
+	if exceedsSigma {
+		return fmt.Sprintf("change of %+.1f%% since %s is %.1f sigma, limit %.1f",
+			change*100, previous.Date.Format("2006-01-02"), sigmas, c.MaxSigma)
+	}
+
+	return ""
+}
+
+// checkPeers compares against the median of other sources' daily rates for
+// the pair, inverse quotes included
+func (c Config) checkPeers(rate models.Rate, peers []models.Rate) string {
+	if c.MaxSourceDeviation <= 0 {
+		return ""
+	}
+
+	var values []float64
+	var sources []string
+
+	for _, peer := range peers {
+		isComparable := (peer.Source != rate.Source && peer.Frequency == rate.Frequency)
+		if !isComparable {
+			continue
+		}
+
+		switch {
+		case peer.Base == rate.Base && peer.Target == rate.Target:
+			values = append(values, peer.Value)
+		case peer.Base == rate.Target && peer.Target == rate.Base:
+			values = append(values, 1/peer.Value)
+		default:
+			continue
+		}
+
+		if !slices.Contains(sources, peer.Source) {
+			sources = append(sources, peer.Source)
+		}
+	}
+
+	noPeers := (len(values) == 0)
+	if noPeers {
+		return ""
+	}
+
+	deviation := rate.Value/median(values) - 1
+
+	exceedsDeviation := (math.Abs(deviation) > c.MaxSourceDeviation)
+	if exceedsDeviation {
+		return fmt.Sprintf("differs from %s by %+.1f%%, limit %.1f%%",
+			strings.Join(sources, ", "), deviation*100, c.MaxSourceDeviation*100)
+	}
+
+	return ""
+}
+
+func sameFrequency(rates []models.Rate, frequency string) []models.Rate {
+	var result []models.Rate
+	for _, rate := range rates {
+		if rate.Frequency == frequency {
+			result = append(result, rate)
+		}
+	}
+	return result
+}
+
+func standardDeviation(values []float64) float64 {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(values)))
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	isEven := (len(sorted)%2 == 0)
+	if isEven {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
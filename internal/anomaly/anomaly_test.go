@@ -0,0 +1,148 @@
+package anomaly
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+var start = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+func rate(source string, day int, value float64) models.Rate {
+	return models.Rate{
+		Base:      "EUR",
+		Target:    "USD",
+		Value:     value,
+		Date:      start.AddDate(0, 0, day),
+		Source:    source,
+		Fetched:   time.Now(),
+		Frequency: models.FrequencyDaily,
+	}
+}
+
+// ten days wobbling around 1.08
+func history() []models.Rate {
+	var rates []models.Rate
+	for i, v := range []float64{1.080, 1.082, 1.079, 1.081, 1.083, 1.080, 1.078, 1.081, 1.082, 1.080} {
+		rates = append(rates, rate("ECB", i, v))
+	}
+	return rates
+}
+
+func TestCheck(t *testing.T) {
+	cfg := DefaultConfig
+	peers := []models.Rate{
+		rate("BIS", 10, 1.081),
+		// stored inverse quotes count as peers too
+		{Base: "USD", Target: "EUR", Value: 1 / 1.079, Date: start.AddDate(0, 0, 10), Source: "MUFG", Frequency: models.FrequencyDaily},
+	}
+
+	cases := map[string]struct {
+		value      float64
+		peers      []models.Rate
+		suspicious bool
+		mention    string
+	}{
+		"ordinary move":   {1.083, peers, false, ""},
+		"decimal shift":   {10.81, peers, true, "change"},
+		"inverted quote":  {1 / 1.081, peers, true, "change"},
+		"sigma breach":    {1.11, nil, true, "sigma"},
+		"source mismatch": {1.081, []models.Rate{rate("BIS", 10, 1.20), rate("IMF", 10, 1.21)}, true, "BIS, IMF"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			reason := cfg.Check(rate("ECB", 10, tc.value), history(), tc.peers)
+
+			isSuspicious := (reason != "")
+			if isSuspicious != tc.suspicious {
+				t.Fatalf("got reason %q, want suspicious=%v", reason, tc.suspicious)
+			}
+			if !strings.Contains(reason, tc.mention) {
+				t.Errorf("reason %q should mention %q", reason, tc.mention)
+			}
+		})
+	}
+}
+
+func TestStoreAndReview(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fxgo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	if err := database.InsertRates(ctx, history()); err != nil {
+		t.Fatal(err)
+	}
+
+	detector := New(database, DefaultConfig)
+	incoming := []models.Rate{rate("ECB", 10, 1.081), rate("ECB", 11, 108.2), rate("ECB", 12, 1.082)}
+
+	result, err := detector.Store(ctx, incoming)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Inserted != 2 || result.Quarantined != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	pending, err := database.GetQuarantined(ctx, models.QuarantinePending)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Rate.Value != 108.2 {
+		t.Fatalf("unexpected pending rates: %+v", pending)
+	}
+
+	t.Run("reject keeps the rate out", func(t *testing.T) {
+		if err := database.RejectQuarantined(ctx, pending[0].ID); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := database.GetRate(ctx, start.AddDate(0, 0, 11), "EUR", "USD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored != nil {
+			t.Errorf("rejected rate was stored: %+v", stored)
+		}
+
+		if err := database.ApproveQuarantined(ctx, pending[0].ID); err == nil {
+			t.Error("approving a rejected rate should fail")
+		}
+	})
+
+	t.Run("approve stores the rate", func(t *testing.T) {
+		if _, err := detector.Store(ctx, []models.Rate{rate("ECB", 13, 0.5)}); err != nil {
+			t.Fatal(err)
+		}
+
+		pending, err := database.GetQuarantined(ctx, models.QuarantinePending)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("expected one pending rate, got %d", len(pending))
+		}
+
+		if err := database.ApproveQuarantined(ctx, pending[0].ID); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := database.GetRate(ctx, start.AddDate(0, 0, 13), "EUR", "USD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		correctValue := (stored != nil && stored.Value == 0.5)
+		if !correctValue {
+			t.Errorf("approved rate not stored: %+v", stored)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+// Package calendar knows when sources are expected to publish: rule-based
+// holiday calendars per market, and a registry mapping sources to them.
+package calendar
+
+import (
+	"fmt"
+	"time"
+)
+
+type Calendar interface {
+	Name() string
+	// Closed reports whether no publication is expected on the date, and why
+	Closed(date time.Time) (reason string, closed bool)
+}
+
+// Rule reports a named holiday for a date
+type Rule func(date time.Time) (name string, ok bool)
+
+// Rules is a Calendar closed on weekends and on any day matched by a rule
+type Rules struct {
+	name  string
+	rules []Rule
+}
+
+func NewRules(name string, rules ...Rule) *Rules {
+	return &Rules{name: name, rules: rules}
+}
+
+func (r *Rules) Name() string {
+	return r.name
+}
+
+func (r *Rules) Closed(date time.Time) (string, bool) {
+	weekday := date.Weekday()
+	isWeekend := (weekday == time.Saturday || weekday == time.Sunday)
+	if isWeekend {
+		return weekday.String(), true
+	}
+
+	for _, rule := range r.rules {
+		if name, ok := rule(date); ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func IsBusinessDay(c Calendar, date time.Time) bool {
+	_, closed := c.Closed(date)
+	return !closed
+}
+
+// BusinessDays lists every business day between from and to, both inclusive
+func BusinessDays(c Calendar, from, to time.Time) []time.Time {
+	var days []time.Time
+	for day := truncateDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if IsBusinessDay(c, day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// Explain describes why a calendar has no publication on the date, or returns ""
+func Explain(c Calendar, date time.Time) string {
+	reason, closed := c.Closed(date)
+	if !closed {
+		return ""
+	}
+	return fmt.Sprintf("%s is not a business day for %s (%s)", date.Format("2006-01-02"), c.Name(), reason)
+}
+
+// Fixed matches the same day every year
+func Fixed(month time.Month, day int, name string) Rule {
+	return func(date time.Time) (string, bool) {
+		_, m, d := date.Date()
+		return name, m == month && d == day
+	}
+}
+
+// Observed matches a fixed day, moved to the following monday when it falls on a weekend
+func Observed(month time.Month, day int, name string) Rule {
+	return func(date time.Time) (string, bool) {
+		holiday := time.Date(date.Year(), month, day, 0, 0, 0, 0, time.UTC)
+		return name, sameDay(observedDay(holiday), date)
+	}
+}
+
+// NthWeekday matches e.g. the second monday of october, n = -1 is the last one
+func NthWeekday(month time.Month, weekday time.Weekday, n int, name string) Rule {
+	return func(date time.Time) (string, bool) {
+		_, m, d := date.Date()
+		isCandidate := (m == month && date.Weekday() == weekday)
+		if !isCandidate {
+			return name, false
+		}
+
+		isLast := (n == -1)
+		if isLast {
+			return name, date.AddDate(0, 0, 7).Month() != month
+		}
+
+		return name, (d-1)/7 == n-1
+	}
+}
+
+// EasterOffset matches a day relative to western easter sunday, e.g. -2 for good friday
+func EasterOffset(days int, name string) Rule {
+	return func(date time.Time) (string, bool) {
+		return name, sameDay(Easter(date.Year()).AddDate(0, 0, days), date)
+	}
+}
+
+// Since limits a rule to the years it has been in force
+func Since(year int, rule Rule) Rule {
+	return func(date time.Time) (string, bool) {
+		if date.Year() < year {
+			return "", false
+		}
+		return rule(date)
+	}
+}
+
+// Easter computes western easter sunday with the anonymous gregorian algorithm
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func observedDay(holiday time.Time) time.Time {
+	switch holiday.Weekday() {
+	case time.Saturday:
+		return holiday.AddDate(0, 0, 2)
+	case time.Sunday:
+		return holiday.AddDate(0, 0, 1)
+	}
+	return holiday
+}
+
+func sameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendars(t *testing.T) {
+	cases := []struct {
+		calendar Calendar
+		closed   map[string]string
+		open     []string
+	}{
+		{
+			calendar: TARGET2,
+			closed: map[string]string{
+				"2025-04-18": "Good Friday",
+				"2025-04-21": "Easter Monday",
+				"2025-05-01": "Labour Day",
+				"2025-12-26": "Boxing Day",
+				"2025-10-18": "Saturday",
+			},
+			open: []string{"2025-10-17", "2025-07-01", "2025-05-19"},
+		},
+		{
+			calendar: Canada,
+			closed: map[string]string{
+				"2025-05-19": "Victoria Day",
+				"2025-08-04": "Civic Holiday",
+				"2025-09-30": "National Day for Truth and Reconciliation",
+				"2025-10-13": "Thanksgiving",
+				"2022-12-27": "Boxing Day",
+				"2022-12-26": "Christmas Day",
+				"2021-12-28": "Boxing Day",
+				"2023-01-02": "New Year's Day",
+			},
+			open: []string{"2025-10-17", "2025-04-21", "2020-09-30"},
+		},
+		{
+			calendar: Japan,
+			closed: map[string]string{
+				"2025-01-02": "New Year bank holiday",
+				"2025-01-13": "Coming of Age Day",
+				"2025-03-20": "Vernal Equinox Day",
+				"2025-05-06": "substitute holiday",
+				"2025-11-24": "substitute holiday",
+				"2026-09-22": "citizens' holiday",
+				"2025-12-31": "year-end bank holiday",
+			},
+			open: []string{"2025-10-17", "2025-05-07", "2025-12-30"},
+		},
+	}
+
+	for _, tc := range cases {
+		for dateStr, want := range tc.closed {
+			date, _ := time.Parse("2006-01-02", dateStr)
+			got, closed := tc.calendar.Closed(date)
+			if !closed || got != want {
+				t.Errorf("%s: Closed(%s) = %q, %v, want %q", tc.calendar.Name(), dateStr, got, closed, want)
+			}
+		}
+
+		for _, dateStr := range tc.open {
+			date, _ := time.Parse("2006-01-02", dateStr)
+			if got, closed := tc.calendar.Closed(date); closed {
+				t.Errorf("%s: Closed(%s) = %q, want open", tc.calendar.Name(), dateStr, got)
+			}
+		}
+	}
+}
+
+func TestBusinessDays(t *testing.T) {
+	from := time.Date(2025, 12, 22, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// christmas week: 25, 26 and new year's day are closed, plus two weekend days
+	days := BusinessDays(TARGET2, from, to)
+	if len(days) != 7 {
+		t.Errorf("got %d business days, want 7: %v", len(days), days)
+	}
+
+	if ExpectsData("ECB", time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Error("ECB should not publish on christmas")
+	}
+
+	if !ExpectsData("UNKNOWN", time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Error("sources without a calendar should publish on weekdays")
+	}
+}
@@ -0,0 +1,53 @@
+package calendar
+
+import "time"
+
+// Canada follows the Bank of Canada's closures: federal statutory holidays plus
+// the civic holiday, moved to a weekday when they fall on a weekend
+var Canada Calendar = NewRules("Canada",
+	Observed(time.January, 1, "New Year's Day"),
+	EasterOffset(-2, "Good Friday"),
+	victoriaDay,
+	Observed(time.July, 1, "Canada Day"),
+	NthWeekday(time.August, time.Monday, 1, "Civic Holiday"),
+	NthWeekday(time.September, time.Monday, 1, "Labour Day"),
+	Since(2021, Observed(time.September, 30, "National Day for Truth and Reconciliation")),
+	NthWeekday(time.October, time.Monday, 2, "Thanksgiving"),
+	Observed(time.November, 11, "Remembrance Day"),
+	christmas,
+)
+
+// victoriaDay is the last monday before may 25
+func victoriaDay(date time.Time) (string, bool) {
+	_, m, d := date.Date()
+	isCandidate := (m == time.May && date.Weekday() == time.Monday)
+	return "Victoria Day", isCandidate && d >= 18 && d <= 24
+}
+
+// christmas and boxing day are observed on consecutive weekdays when either hits a weekend
+func christmas(date time.Time) (string, bool) {
+	year := date.Year()
+	christmasDay := time.Date(year, time.December, 25, 0, 0, 0, 0, time.UTC)
+
+	observedChristmas := christmasDay
+	observedBoxing := christmasDay.AddDate(0, 0, 1)
+
+	switch christmasDay.Weekday() {
+	case time.Friday:
+		observedBoxing = christmasDay.AddDate(0, 0, 3)
+	case time.Saturday:
+		observedChristmas = christmasDay.AddDate(0, 0, 2)
+		observedBoxing = christmasDay.AddDate(0, 0, 3)
+	case time.Sunday:
+		observedChristmas = christmasDay.AddDate(0, 0, 1)
+		observedBoxing = christmasDay.AddDate(0, 0, 2)
+	}
+
+	if sameDay(date, observedChristmas) {
+		return "Christmas Day", true
+	}
+	if sameDay(date, observedBoxing) {
+		return "Boxing Day", true
+	}
+	return "", false
+}
@@ -0,0 +1,124 @@
+package calendar
+
+import "time"
+
+// Japan covers bank closures: weekends, national holidays (with substitute and
+// citizens' holidays) and the new year closure on january 2-3 and december 31
+var Japan Calendar = japan{}
+
+type japan struct{}
+
+func (japan) Name() string {
+	return "Japan"
+}
+
+func (japan) Closed(date time.Time) (string, bool) {
+	weekday := date.Weekday()
+	isWeekend := (weekday == time.Saturday || weekday == time.Sunday)
+	if isWeekend {
+		return weekday.String(), true
+	}
+
+	return japanBankHoliday(date)
+}
+
+func japanBankHoliday(date time.Time) (string, bool) {
+	_, month, day := date.Date()
+
+	isNewYearClosure := (month == time.January && (day == 2 || day == 3))
+	if isNewYearClosure {
+		return "New Year bank holiday", true
+	}
+
+	isYearEnd := (month == time.December && day == 31)
+	if isYearEnd {
+		return "year-end bank holiday", true
+	}
+
+	return japanNationalHoliday(date)
+}
+
+// japanNationalHoliday follows the Act on National Holidays as amended for 2020 onwards
+// the one-off olympic reshuffles of 2020 and 2021 are not modelled
+func japanNationalHoliday(date time.Time) (string, bool) {
+	if name, ok := japanFixedHoliday(date); ok {
+		return name, true
+	}
+
+	// a holiday falling on sunday moves to the next day that is not a holiday
+	for prev := date.AddDate(0, 0, -1); ; prev = prev.AddDate(0, 0, -1) {
+		if _, ok := japanFixedHoliday(prev); !ok {
+			break
+		}
+		if prev.Weekday() == time.Sunday {
+			return "substitute holiday", true
+		}
+	}
+
+	// a day sandwiched between two holidays becomes a holiday itself
+	_, before := japanFixedHoliday(date.AddDate(0, 0, -1))
+	_, after := japanFixedHoliday(date.AddDate(0, 0, 1))
+	isSandwiched := before && after && date.Weekday() != time.Sunday
+	if isSandwiched {
+		return "citizens' holiday", true
+	}
+
+	return "", false
+}
+
+func japanFixedHoliday(date time.Time) (string, bool) {
+	year, month, day := date.Date()
+
+	switch {
+	case month == time.January && day == 1:
+		return "New Year's Day", true
+	case month == time.January && isNthMonday(date, 2):
+		return "Coming of Age Day", true
+	case month == time.February && day == 11:
+		return "National Foundation Day", true
+	case month == time.February && day == 23:
+		return "Emperor's Birthday", true
+	case month == time.March && day == vernalEquinox(year):
+		return "Vernal Equinox Day", true
+	case month == time.April && day == 29:
+		return "Showa Day", true
+	case month == time.May && day == 3:
+		return "Constitution Memorial Day", true
+	case month == time.May && day == 4:
+		return "Greenery Day", true
+	case month == time.May && day == 5:
+		return "Children's Day", true
+	case month == time.July && isNthMonday(date, 3):
+		return "Marine Day", true
+	case month == time.August && day == 11:
+		return "Mountain Day", true
+	case month == time.September && isNthMonday(date, 3):
+		return "Respect for the Aged Day", true
+	case month == time.September && day == autumnalEquinox(year):
+		return "Autumnal Equinox Day", true
+	case month == time.October && isNthMonday(date, 2):
+		return "Sports Day", true
+	case month == time.November && day == 3:
+		return "Culture Day", true
+	case month == time.November && day == 23:
+		return "Labour Thanksgiving Day", true
+	}
+
+	return "", false
+}
+
+func isNthMonday(date time.Time, n int) bool {
+	isMonday := (date.Weekday() == time.Monday)
+	return isMonday && (date.Day()-1)/7 == n-1
+}
+
+// equinox approximations published by the national astronomical observatory, valid 1980-2099
+func vernalEquinox(year int) int {
+	y := float64(year - 1980)
+	return int(20.8431 + 0.242194*y - float64(int(y/4)))
+}
+
+func autumnalEquinox(year int) int {
+	y := float64(year - 1980)
+	return int(23.2488 + 0.242194*y - float64(int(y/4)))
+}
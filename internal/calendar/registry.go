@@ -0,0 +1,49 @@
+package calendar
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	mu      sync.RWMutex
+	sources = map[string]Calendar{
+		"ECB":          TARGET2,
+		"BankOfCanada": Canada,
+		"MUFG":         Japan,
+	}
+)
+
+// Register sets or replaces the calendar for a source
+func Register(source string, c Calendar) {
+	mu.Lock()
+	defer mu.Unlock()
+	sources[source] = c
+}
+
+func ForSource(source string) (Calendar, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	c, ok := sources[source]
+	return c, ok
+}
+
+// ExpectsData reports whether the source should publish on the date,
+// sources without a calendar are assumed to publish on every weekday
+func ExpectsData(source string, date time.Time) bool {
+	c, ok := ForSource(source)
+	if !ok {
+		weekday := date.Weekday()
+		return weekday != time.Saturday && weekday != time.Sunday
+	}
+	return IsBusinessDay(c, date)
+}
+
+// ExplainSource is Explain for a source's calendar, "" when the source has none
+func ExplainSource(source string, date time.Time) string {
+	c, ok := ForSource(source)
+	if !ok {
+		return ""
+	}
+	return Explain(c, date)
+}
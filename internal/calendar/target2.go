@@ -0,0 +1,13 @@
+package calendar
+
+import "time"
+
+// TARGET2 closing days, the ECB publishes no reference rates on them
+var TARGET2 Calendar = NewRules("TARGET2",
+	Fixed(time.January, 1, "New Year's Day"),
+	EasterOffset(-2, "Good Friday"),
+	EasterOffset(1, "Easter Monday"),
+	Fixed(time.May, 1, "Labour Day"),
+	Fixed(time.December, 25, "Christmas Day"),
+	Fixed(time.December, 26, "Boxing Day"),
+)
@@ -0,0 +1,109 @@
+// Package catalog keeps each provider's live currency catalogue, discovered
+// upstream and cached in the database between refreshes.
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+)
+
+const (
+	DefaultRefreshInterval = 7 * 24 * time.Hour
+	// longer than any run of consecutive holidays, so only stopped series are flagged
+	DefaultStaleAfter = 14 * 24 * time.Hour
+)
+
+type Catalog struct {
+	db              db.Store
+	refreshInterval time.Duration
+	staleAfter      time.Duration
+	now             func() time.Time
+}
+
+func New(database db.Store, refreshInterval, staleAfter time.Duration) *Catalog {
+	return &Catalog{
+		db:              database,
+		refreshInterval: refreshInterval,
+		staleAfter:      staleAfter,
+		now:             time.Now,
+	}
+}
+
+// Currencies returns the cached catalogue, refreshing it once the interval has
+// passed, and updates the provider's supported currencies to the active ones
+func (c *Catalog) Currencies(ctx context.Context, p provider.Discoverer) ([]models.CurrencyInfo, error) {
+	cached, err := c.db.GetCurrencies(ctx, p.Name())
+	if err != nil {
+		return nil, fmt.Errorf("loading cached currencies: %w", err)
+	}
+
+	hasCache := (len(cached) > 0)
+	isFresh := hasCache && c.now().Sub(cached[0].Refreshed) < c.refreshInterval
+	if isFresh {
+		c.apply(p, cached)
+		return cached, nil
+	}
+
+	infos, err := c.Refresh(ctx, p)
+	if err != nil && hasCache {
+		// keep serving the previous catalogue until the source is reachable again
+		c.apply(p, cached)
+		return cached, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// Refresh discovers the catalogue upstream regardless of the cache
+func (c *Catalog) Refresh(ctx context.Context, p provider.Discoverer) ([]models.CurrencyInfo, error) {
+	infos, err := p.DiscoverCurrencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("discovering %s currencies: %w", p.Name(), err)
+	}
+
+	noCurrencies := (len(infos) == 0)
+	if noCurrencies {
+		return nil, fmt.Errorf("discovering %s currencies: empty catalogue", p.Name())
+	}
+
+	now := c.now()
+	for i := range infos {
+		infos[i].Source = p.Name()
+		infos[i].Refreshed = now
+		infos[i].Active = c.isActive(infos[i], now)
+	}
+
+	if err := c.db.ReplaceCurrencies(ctx, p.Name(), infos); err != nil {
+		return nil, fmt.Errorf("storing %s currencies: %w", p.Name(), err)
+	}
+
+	c.apply(p, infos)
+	return infos, nil
+}
+
+// isActive flags series that stopped updating, like the ECB's suspended RUB
+func (c *Catalog) isActive(info models.CurrencyInfo, now time.Time) bool {
+	neverObserved := info.LastDate.IsZero()
+	if neverObserved {
+		return false
+	}
+	return now.Sub(info.LastDate) <= c.staleAfter
+}
+
+func (c *Catalog) apply(p provider.Discoverer, infos []models.CurrencyInfo) {
+	var active []string
+	for _, info := range infos {
+		if info.Active {
+			active = append(active, info.Currency)
+		}
+	}
+	p.SetSupportedCurrencies(active)
+}
@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+type stubDiscoverer struct {
+	infos     []models.CurrencyInfo
+	calls     int
+	supported []string
+}
+
+func (s *stubDiscoverer) Name() string { return "STUB" }
+func (s *stubDiscoverer) Base() string { return "EUR" }
+
+func (s *stubDiscoverer) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	return models.RateResult{}, nil
+}
+
+func (s *stubDiscoverer) SupportedCurrencies() []string { return s.supported }
+
+func (s *stubDiscoverer) SetSupportedCurrencies(currencies []string) { s.supported = currencies }
+
+func (s *stubDiscoverer) DiscoverCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+	s.calls++
+	return slices.Clone(s.infos), nil
+}
+
+func TestCurrencies(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fxgo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	now := time.Date(2025, 10, 17, 12, 0, 0, 0, time.UTC)
+	stub := &stubDiscoverer{infos: []models.CurrencyInfo{
+		{Currency: "USD", FirstDate: time.Date(1999, 1, 4, 0, 0, 0, 0, time.UTC), LastDate: time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{Currency: "RUB", FirstDate: time.Date(1999, 1, 4, 0, 0, 0, 0, time.UTC), LastDate: time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	c := New(database, DefaultRefreshInterval, DefaultStaleAfter)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	infos, err := c.Currencies(ctx, stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 {
+		t.Fatalf("got %d currencies, want 2", len(infos))
+	}
+
+	if !slices.Equal(stub.supported, []string{"USD"}) {
+		t.Errorf("supported = %v, want [USD]", stub.supported)
+	}
+
+	// served from the database within the refresh interval
+	now = now.Add(24 * time.Hour)
+	cached, err := c.Currencies(ctx, stub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stub.calls != 1 {
+		t.Errorf("discovered %d times, want 1", stub.calls)
+	}
+
+	for _, info := range cached {
+		isRUB := info.Currency == "RUB"
+		if isRUB && (info.Active || info.FirstDate.Year() != 1999) {
+			t.Errorf("invalid cached currency: %+v", info)
+		}
+	}
+
+	now = now.Add(DefaultRefreshInterval)
+	if _, err := c.Currencies(ctx, stub); err != nil {
+		t.Fatal(err)
+	}
+
+	if stub.calls != 2 {
+		t.Errorf("discovered %d times, want 2", stub.calls)
+	}
+}
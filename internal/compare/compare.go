@@ -0,0 +1,228 @@
+// Package compare lines up the rates several sources give for the same pair
+// and measures how far apart they are, which also catches parser regressions
+// in a single provider.
+package compare
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/triangulate"
+)
+
+// DefaultThreshold flags days where sources are more than 1% apart
+const DefaultThreshold = 0.01
+
+type Options struct {
+	// Sources limits the comparison, all stored sources are used if empty
+	Sources []string
+	// Threshold is the spread above which a day is flagged
+	Threshold float64
+}
+
+// Quote is one source's rate for a pair, Derived if it came from that
+// source's own cross or inverse rather than a stored observation
+type Quote struct {
+	Source  string
+	Value   float64
+	Derived bool
+}
+
+// Row is one pair on one day, quoted by at least two sources
+type Row struct {
+	Date   time.Time
+	Base   string
+	Target string
+	Quotes []Quote
+	// Spread is max/min - 1 over the quotes
+	Spread  float64
+	Flagged bool
+}
+
+type PairSummary struct {
+	Base      string
+	Target    string
+	Sources   []string
+	Days      int
+	Flagged   int
+	MaxSpread float64
+	// MeanSpread averages the daily spreads
+	MeanSpread float64
+}
+
+type Report struct {
+	From      time.Time
+	To        time.Time
+	Threshold float64
+	Rows      []Row
+	Pairs     []PairSummary
+}
+
+// Compare takes every daily pair stored natively by some source and asks
+// each other source's own rates for the same pair on that day, so e.g.
+// ECB's EUR/CAD is compared with Bank of Canada's inverted CAD/EUR.
+// Monthly averages are left out, they aren't comparable to daily fixings.
+func Compare(ctx context.Context, database db.Store, from, to time.Time, opts Options) (Report, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultThreshold
+	}
+
+	stored, err := database.GetDirectRatesBetween(ctx, from, to, "")
+	if err != nil {
+		return Report{}, fmt.Errorf("loading stored rates: %w", err)
+	}
+
+	report := Report{From: from, To: to, Threshold: opts.Threshold}
+
+	// rows are ordered by date, so each day is a contiguous run
+	for start := 0; start < len(stored); {
+		end := start
+		for end < len(stored) && stored[end].Date.Equal(stored[start].Date) {
+			end++
+		}
+
+		rows := compareDay(stored[start:end], opts)
+		report.Rows = append(report.Rows, rows...)
+		start = end
+	}
+
+	report.Pairs = summarize(report.Rows)
+	return report, nil
+}
+
+type pair struct{ base, target string }
+
+func compareDay(rates []models.Rate, opts Options) []Row {
+	bySource := make(map[string][]models.Rate)
+	var sources []string
+
+	for _, rate := range rates {
+		isComparable := (rate.Frequency != models.FrequencyMonthly && !rate.Calculated)
+		isSelected := (len(opts.Sources) == 0 || slices.Contains(opts.Sources, rate.Source))
+		if !isComparable || !isSelected {
+			continue
+		}
+
+		if bySource[rate.Source] == nil {
+			sources = append(sources, rate.Source)
+		}
+		bySource[rate.Source] = append(bySource[rate.Source], rate)
+	}
+
+	tooFewSources := (len(sources) < 2)
+	if tooFewSources {
+		return nil
+	}
+	slices.Sort(sources)
+
+	// each unordered pair is compared once, in the direction it was first stored
+	var pairs []pair
+	seen := make(map[pair]bool)
+	for _, source := range sources {
+		for _, rate := range bySource[source] {
+			p := pair{rate.Base, rate.Target}
+			if seen[p] || seen[pair{p.target, p.base}] {
+				continue
+			}
+			seen[p] = true
+			pairs = append(pairs, p)
+		}
+	}
+
+	graphs := make(map[string]*triangulate.Graph, len(sources))
+	for _, source := range sources {
+		graphs[source] = triangulate.NewGraph(bySource[source], nil)
+	}
+
+	var rows []Row
+	for _, p := range pairs {
+		var quotes []Quote
+		for _, source := range sources {
+			result, err := graphs[source].Convert(p.base, p.target)
+			if err != nil {
+				continue
+			}
+			quotes = append(quotes, Quote{Source: source, Value: result.Rate.Value, Derived: result.Rate.Calculated})
+		}
+
+		tooFewQuotes := (len(quotes) < 2)
+		if tooFewQuotes {
+			continue
+		}
+
+		spread := spreadOf(quotes)
+		rows = append(rows, Row{
+			Date:    rates[0].Date,
+			Base:    p.base,
+			Target:  p.target,
+			Quotes:  quotes,
+			Spread:  spread,
+			Flagged: spread > opts.Threshold,
+		})
+	}
+
+	slices.SortFunc(rows, func(a, b Row) int {
+		if a.Base != b.Base {
+			return cmp.Compare(a.Base, b.Base)
+		}
+		return cmp.Compare(a.Target, b.Target)
+	})
+
+	return rows
+}
+
+func summarize(rows []Row) []PairSummary {
+	index := make(map[pair]int)
+	var summaries []PairSummary
+
+	for _, row := range rows {
+		p := pair{row.Base, row.Target}
+		i, ok := index[p]
+		if !ok {
+			i = len(summaries)
+			index[p] = i
+			summaries = append(summaries, PairSummary{Base: row.Base, Target: row.Target})
+		}
+
+		s := &summaries[i]
+		s.Days++
+		s.MeanSpread += row.Spread
+		s.MaxSpread = max(s.MaxSpread, row.Spread)
+		if row.Flagged {
+			s.Flagged++
+		}
+		for _, q := range row.Quotes {
+			if !slices.Contains(s.Sources, q.Source) {
+				s.Sources = append(s.Sources, q.Source)
+			}
+		}
+	}
+
+	for i := range summaries {
+		summaries[i].MeanSpread /= float64(summaries[i].Days)
+		slices.Sort(summaries[i].Sources)
+	}
+
+	slices.SortFunc(summaries, func(a, b PairSummary) int {
+		if a.Base != b.Base {
+			return cmp.Compare(a.Base, b.Base)
+		}
+		return cmp.Compare(a.Target, b.Target)
+	})
+
+	return summaries
+}
+
+func spreadOf(quotes []Quote) float64 {
+	low, high := quotes[0].Value, quotes[0].Value
+	for _, q := range quotes[1:] {
+		low = min(low, q.Value)
+		high = max(high, q.Value)
+	}
+	return high/low - 1
+}
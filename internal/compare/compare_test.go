@@ -0,0 +1,97 @@
+package compare
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestCompare(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fxgo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	day1 := time.Date(2025, 6, 2, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	rate := func(date time.Time, source, base, target string, value float64) models.Rate {
+		return models.Rate{Base: base, Target: target, Value: value, Date: date, Source: source, Fetched: time.Now(), Frequency: models.FrequencyDaily}
+	}
+
+	rates := []models.Rate{
+		rate(day1, "ECB", "EUR", "CAD", 1.50),
+		rate(day1, "ECB", "EUR", "USD", 1.10),
+		rate(day1, "BankOfCanada", "CAD", "EUR", 1/1.5015),
+		rate(day1, "BankOfCanada", "CAD", "USD", 0.733),
+		// a decimal shift in one source on the second day
+		rate(day2, "ECB", "EUR", "CAD", 15.0),
+		rate(day2, "BankOfCanada", "CAD", "EUR", 1/1.50),
+		// monthly averages are not compared
+		{Base: "USD", Target: "CAD", Value: 1.6, Date: day1, Source: "BIS", Fetched: time.Now(), Frequency: models.FrequencyMonthly},
+	}
+	if err := database.InsertRates(ctx, rates); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Compare(ctx, database, day1, day2, Options{Threshold: 0.005})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("pairs line up across sources", func(t *testing.T) {
+		// CAD/EUR on both days, CAD/USD and EUR/USD on the first; pairs
+		// keep the direction of the first source alphabetically
+		if len(report.Rows) != 4 {
+			t.Fatalf("got %d rows, want 4: %+v", len(report.Rows), report.Rows)
+		}
+
+		cadUSD := report.Rows[1]
+		correctPair := (cadUSD.Base == "CAD" && cadUSD.Target == "USD")
+		if !correctPair {
+			t.Fatalf("unexpected CAD/USD row %s/%s", cadUSD.Base, cadUSD.Target)
+		}
+
+		// ECB's CAD/USD is its own cross 1.10/1.50
+		ecbDerived := (cadUSD.Quotes[1].Source == "ECB" && cadUSD.Quotes[1].Derived)
+		if !ecbDerived || math.Abs(cadUSD.Quotes[1].Value-1.10/1.50) > 1e-9 {
+			t.Errorf("unexpected ECB quote %+v", cadUSD.Quotes[1])
+		}
+		if cadUSD.Flagged {
+			t.Errorf("CAD/USD spread %.4f should be within threshold", cadUSD.Spread)
+		}
+	})
+
+	t.Run("divergent day is flagged", func(t *testing.T) {
+		var flagged []Row
+		for _, row := range report.Rows {
+			if row.Flagged {
+				flagged = append(flagged, row)
+			}
+		}
+
+		correct := (len(flagged) == 1 && flagged[0].Date.Equal(day2) && flagged[0].Spread > 8)
+		if !correct {
+			t.Errorf("unexpected flagged rows: %+v", flagged)
+		}
+	})
+
+	t.Run("summary per pair", func(t *testing.T) {
+		if len(report.Pairs) != 3 {
+			t.Fatalf("got %d pairs, want 3", len(report.Pairs))
+		}
+
+		cadEUR := report.Pairs[0]
+		correct := (cadEUR.Base == "CAD" && cadEUR.Target == "EUR" && cadEUR.Days == 2 && cadEUR.Flagged == 1)
+		if !correct {
+			t.Errorf("unexpected CAD/EUR summary: %+v", cadEUR)
+		}
+	})
+}
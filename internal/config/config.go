@@ -0,0 +1,288 @@
+// Package config reads the settings of an instance from a YAML file, with
+// FXGO_* environment variables taking precedence over the file
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // schedules name time zones, minimal images ship no zoneinfo
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// DefaultPath is read when no file is named and it exists
+const DefaultPath = "fxgo.yaml"
+
+type Config struct {
+	Database Database `yaml:"database"`
+	HTTP     HTTP     `yaml:"http"`
+	Server   Server   `yaml:"server"`
+	// Providers is keyed by provider name, providers left out keep their defaults
+	Providers map[string]Provider `yaml:"providers"`
+}
+
+type Database struct {
+	Path        string        `yaml:"path"`
+	JournalMode string        `yaml:"journal_mode"`
+	BusyTimeout time.Duration `yaml:"busy_timeout"`
+}
+
+// HTTP is shared by the clients of all providers
+type HTTP struct {
+	// Timeout of a single request, zero keeps each provider's own default
+	Timeout     time.Duration `yaml:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay"`
+	MaxDelay    time.Duration `yaml:"max_delay"`
+	// RequestsPerSecond is per host, zero disables the limit
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+	// CacheDir keeps responses on disk across restarts, empty keeps them in memory
+	CacheDir        string `yaml:"cache_dir"`
+	MaxResponseSize int64  `yaml:"max_response_size"`
+}
+
+type Server struct {
+	Listen string `yaml:"listen"`
+	Auth   Auth   `yaml:"auth"`
+}
+
+// Auth guards the api with bearer tokens, without tokens it is open
+type Auth struct {
+	Tokens []string `yaml:"tokens"`
+}
+
+type Provider struct {
+	// Enabled defaults to true
+	Enabled *bool `yaml:"enabled"`
+	// Priority orders the fallback chain, lower first, zero keeps the default
+	Priority int    `yaml:"priority"`
+	BaseURL  string `yaml:"base_url"`
+	// Timeout overrides http.timeout for this provider
+	Timeout  time.Duration `yaml:"timeout"`
+	Schedule Schedule      `yaml:"schedule"`
+	// Options are understood by one provider only, e.g. the ECB feed
+	Options map[string]string `yaml:"options"`
+}
+
+func (p Provider) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// Schedule is when the daily fetch runs, empty leaves it to the scheduler
+type Schedule struct {
+	// At is a wall clock time like "16:30"
+	At       string `yaml:"at"`
+	TimeZone string `yaml:"timezone"`
+}
+
+// journal modes sqlite accepts
+var journalModes = []string{"delete", "truncate", "persist", "memory", "wal", "off"}
+
+func Default() Config {
+	return Config{
+		Database: Database{
+			Path:        "fxgo.db",
+			JournalMode: db.DefaultJournalMode,
+			BusyTimeout: db.DefaultBusyTimeout,
+		},
+		HTTP: HTTP{
+			MaxAttempts:       common.DefaultRetryPolicy.MaxAttempts,
+			BaseDelay:         common.DefaultRetryPolicy.BaseDelay,
+			MaxDelay:          common.DefaultRetryPolicy.MaxDelay,
+			RequestsPerSecond: common.DefaultRequestsPerSecond,
+			Burst:             common.DefaultBurst,
+			MaxResponseSize:   common.DefaultMaxResponseSize,
+		},
+		Server: Server{
+			Listen: ":8080",
+		},
+		Providers: make(map[string]Provider),
+	}
+}
+
+// Load reads the file at path over the defaults, applies the environment and
+// validates the result. An empty path skips the file.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("reading config: %w", err)
+		}
+
+		if err := decode(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return Config{}, fmt.Errorf("reading environment: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// decode rejects unknown keys, a typo would otherwise silently keep a default
+func decode(data []byte, cfg *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(cfg)
+	isEmpty := errors.Is(err, io.EOF)
+	if isEmpty {
+		return nil
+	}
+	return err
+}
+
+// Validate reports every invalid setting at once, each prefixed with its key
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Database.Path == "" {
+		invalid("database.path", "must be set")
+	}
+	if !slices.Contains(journalModes, strings.ToLower(c.Database.JournalMode)) {
+		invalid("database.journal_mode", "%q is not one of %v", c.Database.JournalMode, journalModes)
+	}
+	if c.Database.BusyTimeout < 0 {
+		invalid("database.busy_timeout", "must not be negative")
+	}
+
+	if c.HTTP.Timeout < 0 {
+		invalid("http.timeout", "must not be negative")
+	}
+	if c.HTTP.MaxAttempts < 1 {
+		invalid("http.max_attempts", "must be at least 1, got %d", c.HTTP.MaxAttempts)
+	}
+	if c.HTTP.BaseDelay < 0 {
+		invalid("http.base_delay", "must not be negative")
+	}
+	if c.HTTP.MaxDelay < 0 {
+		invalid("http.max_delay", "must not be negative, use 0 for no cap")
+	}
+	if c.HTTP.RequestsPerSecond < 0 {
+		invalid("http.requests_per_second", "must not be negative, use 0 to disable the limit")
+	}
+	limitsRate := (c.HTTP.RequestsPerSecond > 0)
+	if limitsRate && c.HTTP.Burst < 1 {
+		invalid("http.burst", "must be at least 1 when requests are limited")
+	}
+	if c.HTTP.MaxResponseSize <= 0 {
+		invalid("http.max_response_size", "must be positive")
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Listen); err != nil {
+		invalid("server.listen", "%q is not host:port", c.Server.Listen)
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		invalid("server.listen", "%q has an invalid port", c.Server.Listen)
+	}
+	for i, token := range c.Server.Auth.Tokens {
+		isBlank := (strings.TrimSpace(token) == "")
+		if isBlank {
+			invalid("server.auth.tokens", "token %d is empty", i+1)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Providers)) {
+		errs = append(errs, c.Providers[name].validate("providers."+name)...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p Provider) validate(key string) []error {
+	var errs []error
+	invalid := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s.%s: %s", key, field, fmt.Sprintf(format, args...)))
+	}
+
+	if p.Priority < 0 {
+		invalid("priority", "must not be negative")
+	}
+	if p.Timeout < 0 {
+		invalid("timeout", "must not be negative")
+	}
+
+	if p.BaseURL != "" {
+		u, err := url.Parse(p.BaseURL)
+		isHTTP := err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+		if !isHTTP {
+			invalid("base_url", "%q is not an http(s) url", p.BaseURL)
+		}
+	}
+
+	if p.Schedule.At != "" {
+		if _, err := time.Parse("15:04", p.Schedule.At); err != nil {
+			invalid("schedule.at", "%q is not a time like 16:30", p.Schedule.At)
+		}
+	}
+	zoneWithoutTime := (p.Schedule.TimeZone != "" && p.Schedule.At == "")
+	if zoneWithoutTime {
+		invalid("schedule.timezone", "set without schedule.at")
+	}
+	if _, err := p.Schedule.Location(); err != nil {
+		invalid("schedule.timezone", "%v", err)
+	}
+
+	return errs
+}
+
+// Location is the time zone of the schedule, UTC when none is set
+func (s Schedule) Location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.TimeZone)
+}
+
+// Provider looks up the settings of a provider, names match regardless of case
+// since environment variables are upper case
+func (c Config) Provider(name string) Provider {
+	for key, settings := range c.Providers {
+		if strings.EqualFold(key, name) {
+			return settings
+		}
+	}
+	return Provider{}
+}
+
+// NewClient builds an HTTP client from the settings
+func (h HTTP) NewClient(timeout time.Duration) *common.HTTPClient {
+	opts := []common.HTTPOption{
+		common.WithRetry(common.RetryPolicy{
+			MaxAttempts: h.MaxAttempts,
+			BaseDelay:   h.BaseDelay,
+			MaxDelay:    h.MaxDelay,
+		}),
+		common.WithRateLimit(h.RequestsPerSecond, h.Burst),
+		common.WithMaxResponseSize(h.MaxResponseSize),
+	}
+	if h.CacheDir != "" {
+		opts = append(opts, common.WithCacheDir(h.CacheDir))
+	}
+
+	return common.NewHTTPClient(timeout, opts...)
+}
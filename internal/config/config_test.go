@@ -0,0 +1,195 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/replay"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "fxgo.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults without a file", func(t *testing.T) {
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctDefaults := (cfg.Database.Path == "fxgo.db" && cfg.HTTP.MaxAttempts == 4 && cfg.Server.Listen == ":8080")
+		if !correctDefaults {
+			t.Errorf("unexpected defaults: %+v", cfg)
+		}
+	})
+
+	t.Run("file over defaults", func(t *testing.T) {
+		path := writeConfig(t, `
+database:
+  path: /var/lib/fxgo/rates.db
+http:
+  timeout: 45s
+providers:
+  BIS:
+    enabled: false
+  ECB:
+    priority: 5
+    schedule:
+      at: "16:30"
+      timezone: Europe/Berlin
+    options:
+      feed: hist-90d
+`)
+
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctFile := (cfg.Database.Path == "/var/lib/fxgo/rates.db" && cfg.HTTP.Timeout == 45*time.Second)
+		if !correctFile {
+			t.Errorf("file settings not applied: %+v", cfg)
+		}
+
+		// untouched settings keep their defaults
+		if cfg.Database.JournalMode != "wal" || cfg.HTTP.MaxAttempts != 4 {
+			t.Errorf("defaults lost: %+v", cfg)
+		}
+
+		ecb := cfg.Provider("ecb")
+		correctECB := ecb.IsEnabled() && ecb.Priority == 5 && ecb.Schedule.At == "16:30" && ecb.Options["feed"] == "hist-90d"
+		if !correctECB {
+			t.Errorf("unexpected ECB settings: %+v", ecb)
+		}
+
+		if cfg.Provider("BIS").IsEnabled() {
+			t.Error("BIS should be disabled")
+		}
+		if !cfg.Provider("IMF").IsEnabled() {
+			t.Error("providers left out of the file should stay enabled")
+		}
+	})
+
+	t.Run("environment over file", func(t *testing.T) {
+		path := writeConfig(t, `
+database:
+  path: from-file.db
+providers:
+  BankOfCanada:
+    priority: 1
+`)
+
+		t.Setenv("FXGO_DATABASE_PATH", "from-env.db")
+		t.Setenv("FXGO_HTTP_REQUESTS_PER_SECOND", "0.5")
+		t.Setenv("FXGO_SERVER_AUTH_TOKENS", "first, second")
+		t.Setenv("FXGO_PROVIDERS_BANKOFCANADA_ENABLED", "false")
+		t.Setenv("FXGO_PROVIDERS_MUFG_TIMEOUT", "90s")
+		t.Setenv("FXGO_PROVIDERS_ECB_OPTIONS_FEED", "daily")
+
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctEnv := cfg.Database.Path == "from-env.db" &&
+			cfg.HTTP.RequestsPerSecond == 0.5 &&
+			slices.Equal(cfg.Server.Auth.Tokens, []string{"first", "second"})
+		if !correctEnv {
+			t.Errorf("environment not applied: %+v", cfg)
+		}
+
+		boc := cfg.Provider("BankOfCanada")
+		if boc.IsEnabled() || boc.Priority != 1 {
+			t.Errorf("environment should disable BankOfCanada and keep its priority: %+v", boc)
+		}
+		if cfg.Provider("MUFG").Timeout != 90*time.Second {
+			t.Errorf("environment should add settings for MUFG: %+v", cfg.Provider("MUFG"))
+		}
+		if cfg.Provider("ECB").Options["feed"] != "daily" {
+			t.Errorf("environment should set ECB options: %+v", cfg.Provider("ECB"))
+		}
+	})
+
+	t.Run("variables that aren't settings", func(t *testing.T) {
+		t.Setenv(EnvPath, "")
+		t.Setenv(replay.RecordEnv, "1")
+		t.Setenv("FXGO_TEST_POSTGRES_DSN", "postgres://localhost/fxgo_test")
+
+		if _, err := Load(""); err != nil {
+			t.Errorf("expected the variables to be allowed, got %v", err)
+		}
+	})
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Run("every invalid setting is reported", func(t *testing.T) {
+		path := writeConfig(t, `
+database:
+  journal_mode: fast
+http:
+  max_attempts: 0
+server:
+  listen: localhost
+providers:
+  ECB:
+    base_url: ftp://example.com
+    schedule:
+      at: "25:00"
+`)
+
+		_, err := Load(path)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		for _, key := range []string{"database.journal_mode", "http.max_attempts", "server.listen", "providers.ECB.base_url", "providers.ECB.schedule.at"} {
+			if !strings.Contains(err.Error(), key) {
+				t.Errorf("error doesn't mention %s: %v", key, err)
+			}
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		path := writeConfig(t, "database:\n  pth: typo.db\n")
+
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), "pth") {
+			t.Errorf("expected the unknown key to be named, got %v", err)
+		}
+	})
+
+	t.Run("unknown variable", func(t *testing.T) {
+		t.Setenv("FXGO_DATABASE_PTH", "typo.db")
+
+		_, err := Load("")
+		if err == nil || !strings.Contains(err.Error(), "FXGO_DATABASE_PTH") {
+			t.Errorf("expected the unknown variable to be named, got %v", err)
+		}
+	})
+
+	t.Run("malformed variable", func(t *testing.T) {
+		t.Setenv("FXGO_HTTP_TIMEOUT", "30")
+
+		_, err := Load("")
+		if err == nil || !strings.Contains(err.Error(), "FXGO_HTTP_TIMEOUT") {
+			t.Errorf("expected the variable to be named, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
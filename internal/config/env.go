@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// variables are named after the yaml keys, e.g. FXGO_HTTP_TIMEOUT or
+// FXGO_PROVIDERS_ECB_SCHEDULE_AT, lists are comma separated
+const envPrefix = "FXGO"
+
+// EnvPath names the config file, it is read by the command rather than Load
+const EnvPath = "FXGO_CONFIG"
+
+// variables with the prefix that aren't settings: the config file, replay's
+// record switch (replay.RecordEnv) and the PostgreSQL DSN of the db tests
+var otherVariables = []string{EnvPath, "FXGO_RECORD", "FXGO_TEST_POSTGRES_DSN"}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+type environment struct {
+	values map[string]string
+	used   map[string]bool
+}
+
+func (e *environment) lookup(name string) (string, bool) {
+	value, ok := e.values[name]
+	if ok {
+		e.used[name] = true
+	}
+	return value, ok
+}
+
+// applyEnv overrides the settings with the FXGO_* variables of environ,
+// unknown variables are errors just like unknown keys in the file
+func applyEnv(cfg *Config, environ []string) error {
+	env := &environment{values: make(map[string]string), used: make(map[string]bool)}
+	for _, name := range otherVariables {
+		env.used[name] = true
+	}
+	for _, kv := range environ {
+		name, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, envPrefix+"_") {
+			env.values[name] = value
+		}
+	}
+
+	errs := setFields(reflect.ValueOf(cfg).Elem(), envPrefix, env)
+	errs = append(errs, applyProviderEnv(cfg, env)...)
+
+	for _, name := range slices.Sorted(maps.Keys(env.values)) {
+		if !env.used[name] {
+			errs = append(errs, fmt.Errorf("%s: unknown variable", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applyProviderEnv handles FXGO_PROVIDERS_<NAME>_*, which may name providers
+// the file left out
+func applyProviderEnv(cfg *Config, env *environment) []error {
+	prefix := envPrefix + "_PROVIDERS_"
+	if cfg.Providers == nil {
+		cfg.Providers = make(map[string]Provider)
+	}
+
+	var names []string
+	for variable := range env.values {
+		rest, isProvider := strings.CutPrefix(variable, prefix)
+		if !isProvider {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, "_")
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range slices.Compact(names) {
+		key := name
+		for existing := range cfg.Providers {
+			if strings.EqualFold(existing, name) {
+				key = existing
+			}
+		}
+
+		settings := cfg.Providers[key]
+		errs = append(errs, setFields(reflect.ValueOf(&settings).Elem(), prefix+name, env)...)
+
+		optionPrefix := prefix + name + "_OPTIONS_"
+		for variable, value := range env.values {
+			option, isOption := strings.CutPrefix(variable, optionPrefix)
+			if !isOption {
+				continue
+			}
+			if settings.Options == nil {
+				settings.Options = make(map[string]string)
+			}
+			settings.Options[strings.ToLower(option)] = value
+			env.used[variable] = true
+		}
+
+		cfg.Providers[key] = settings
+	}
+
+	return errs
+}
+
+// setFields walks a settings struct, maps are left to their own handling
+func setFields(v reflect.Value, prefix string, env *environment) []error {
+	var errs []error
+	for i := range v.NumField() {
+		field := v.Field(i)
+		name := prefix + "_" + strings.ToUpper(v.Type().Field(i).Tag.Get("yaml"))
+
+		switch field.Kind() {
+		case reflect.Struct:
+			errs = append(errs, setFields(field, name, env)...)
+			continue
+		case reflect.Map:
+			continue
+		}
+
+		value, ok := env.lookup(name)
+		if !ok {
+			continue
+		}
+
+		if err := setValue(field, strings.TrimSpace(value)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration like 30s", value)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		field.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.Slice:
+		var items []string
+		for item := range strings.SplitSeq(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
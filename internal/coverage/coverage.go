@@ -0,0 +1,148 @@
+// Package coverage compares stored observations against the dates a source
+// is expected to publish on, and re-fetches what is missing.
+package coverage
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/anomaly"
+	"github.com/xhos/fxgo/internal/calendar"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+)
+
+// Gap is one expected publication day with the currencies missing from it
+type Gap struct {
+	Date    time.Time
+	Base    string
+	Targets []string
+}
+
+type Report struct {
+	Source string
+	From   time.Time
+	To     time.Time
+	// Expected counts business days in the range
+	Expected int
+	Gaps     []Gap
+}
+
+type HealResult struct {
+	Healed int
+	// Quarantined counts re-fetched rates held back as suspicious
+	Quarantined int
+	// Missing explains the currencies the source still didn't return
+	Missing []string
+	Failed  []error
+}
+
+// Check finds the business days on which a source has no rate for a pair it
+// otherwise publishes in the range. Monthly series are not checked.
+func Check(ctx context.Context, database db.Store, source string, from, to time.Time) (Report, error) {
+	stored, err := database.GetDirectRatesBetween(ctx, from, to, source)
+	if err != nil {
+		return Report{}, fmt.Errorf("loading stored rates: %w", err)
+	}
+
+	type pair struct{ base, target string }
+	seen := make(map[pair]map[string]bool)
+	var pairs []pair
+
+	for _, rate := range stored {
+		isMonthly := (rate.Frequency == models.FrequencyMonthly)
+		if isMonthly {
+			continue
+		}
+
+		p := pair{rate.Base, rate.Target}
+		if seen[p] == nil {
+			seen[p] = make(map[string]bool)
+			pairs = append(pairs, p)
+		}
+		seen[p][dayKey(rate.Date)] = true
+	}
+
+	expected := expectedDays(source, from, to)
+	report := Report{Source: source, From: from, To: to, Expected: len(expected)}
+
+	for _, day := range expected {
+		missing := make(map[string][]string)
+		var bases []string
+
+		for _, p := range pairs {
+			if seen[p][dayKey(day)] {
+				continue
+			}
+			if missing[p.base] == nil {
+				bases = append(bases, p.base)
+			}
+			missing[p.base] = append(missing[p.base], p.target)
+		}
+
+		for _, base := range bases {
+			targets := missing[base]
+			slices.Sort(targets)
+			report.Gaps = append(report.Gaps, Gap{Date: day, Base: base, Targets: targets})
+		}
+	}
+
+	return report, nil
+}
+
+// Heal re-fetches exactly the missing dates and currencies through the
+// provider, screening them like any other incoming rates
+func Heal(ctx context.Context, database db.Store, p provider.Provider, report Report) HealResult {
+	var result HealResult
+	detector := anomaly.New(database, anomaly.DefaultConfig)
+
+	for _, gap := range report.Gaps {
+		fetched, err := p.FetchRates(ctx, models.RateRequest{
+			Base:    gap.Base,
+			Targets: gap.Targets,
+			Date:    gap.Date,
+		})
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Errorf("%s: %w", gap, err))
+			continue
+		}
+
+		for _, target := range fetched.Missing() {
+			result.Missing = append(result.Missing, fmt.Sprintf("%s %s/%s", gap.Date.Format("2006-01-02"), gap.Base, target))
+		}
+
+		stored, err := detector.Store(ctx, fetched.Rates)
+		if err != nil {
+			result.Failed = append(result.Failed, fmt.Errorf("%s: %w", gap, err))
+			continue
+		}
+
+		result.Healed += stored.Inserted
+		result.Quarantined += stored.Quarantined
+	}
+
+	return result
+}
+
+func (g Gap) String() string {
+	return fmt.Sprintf("%s %s/%s", g.Date.Format("2006-01-02"), g.Base, strings.Join(g.Targets, ","))
+}
+
+// expectedDays uses the source's calendar, or every weekday if it has none
+func expectedDays(source string, from, to time.Time) []time.Time {
+	var days []time.Time
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if calendar.ExpectsData(source, day) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
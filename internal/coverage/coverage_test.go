@@ -0,0 +1,98 @@
+package coverage
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+type stubProvider struct {
+	requests []models.RateRequest
+	// withheld currencies are supported but never published
+	withheld []string
+}
+
+func (s *stubProvider) Name() string                  { return "ECB" }
+func (s *stubProvider) Base() string                  { return "EUR" }
+func (s *stubProvider) SupportedCurrencies() []string { return []string{"USD", "JPY"} }
+
+func (s *stubProvider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	s.requests = append(s.requests, req)
+
+	values := map[string]float64{"USD": 1.17, "JPY": 180}
+
+	var rates []models.Rate
+	for _, target := range req.Targets {
+		if slices.Contains(s.withheld, target) {
+			continue
+		}
+		rates = append(rates, models.Rate{Base: req.Base, Target: target, Value: values[target], Date: req.Date, Source: "ECB", Fetched: time.Now()})
+	}
+	return common.NewRateResult(req.Targets, rates, s.SupportedCurrencies(), common.ParseReport{}), nil
+}
+
+func TestCheckAndHeal(t *testing.T) {
+	database, err := db.Open(filepath.Join(t.TempDir(), "fxgo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, 12, d, 0, 0, 0, 0, time.UTC) }
+
+	// 22-24 stored, 25-26 are TARGET2 holidays, 27-28 weekend, 29 missing USD, 30 missing entirely
+	var stored []models.Rate
+	for _, d := range []int{22, 23, 24, 29} {
+		stored = append(stored, models.Rate{Base: "EUR", Target: "JPY", Value: 180, Date: day(d), Source: "ECB", Fetched: time.Now()})
+	}
+	for _, d := range []int{22, 23, 24} {
+		stored = append(stored, models.Rate{Base: "EUR", Target: "USD", Value: 1.17, Date: day(d), Source: "ECB", Fetched: time.Now()})
+	}
+	if err := database.InsertRates(ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Check(ctx, database, "ECB", day(22), day(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"2025-12-29 EUR/USD", "2025-12-30 EUR/JPY,USD"}
+	if len(report.Gaps) != len(want) {
+		t.Fatalf("got gaps %v, want %v", report.Gaps, want)
+	}
+	for i, gap := range report.Gaps {
+		if gap.String() != want[i] {
+			t.Errorf("gap %d = %s, want %s", i, gap, want[i])
+		}
+	}
+
+	withholding := &stubProvider{withheld: []string{"USD"}}
+	partial := Heal(ctx, database, withholding, Report{Gaps: report.Gaps[1:]})
+
+	wantMissing := []string{"2025-12-30 EUR/USD: not_published"}
+	if partial.Healed != 1 || !slices.Equal(partial.Missing, wantMissing) {
+		t.Errorf("unexpected partial heal result: %+v", partial)
+	}
+
+	stub := &stubProvider{}
+	result := Heal(ctx, database, stub, report)
+	if result.Healed != 3 || len(result.Missing) != 0 || len(result.Failed) != 0 {
+		t.Errorf("unexpected heal result: %+v", result)
+	}
+
+	after, err := Check(ctx, database, "ECB", day(22), day(30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Gaps) != 0 {
+		t.Errorf("gaps left after healing: %v", after.Gaps)
+	}
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/calendar"
+	"github.com/xhos/fxgo/internal/models"
+)
+
+const DefaultMaxStaleness = 7 * 24 * time.Hour
+
+type AsOfOptions struct {
+	// MaxStaleness bounds how far from the requested date a rate may be, zero means DefaultMaxStaleness
+	MaxStaleness time.Duration
+	// PreferNext looks for the next publication first, falling back to the previous one
+	PreferNext bool
+	// KnownAt answers from what was stored at that time, zero means now
+	KnownAt time.Time
+}
+
+type AsOfResult struct {
+	Rate          models.Rate
+	RequestedDate time.Time
+	// EffectiveDate is the publication date the rate was taken from
+	EffectiveDate time.Time
+	// Reason explains why the requested date had no rate, empty when it had one
+	Reason string
+}
+
+// GetRateAsOf returns the pair's rate for the date or, on weekends and holidays,
+// the closest publication within MaxStaleness. Unlike GetNearestDate it only
+// considers dates on which this pair can actually be answered.
+func (r *rateReader) GetRateAsOf(ctx context.Context, base, target string, date time.Time, opts AsOfOptions) (*AsOfResult, error) {
+	maxStaleness := opts.MaxStaleness
+	if maxStaleness <= 0 {
+		maxStaleness = DefaultMaxStaleness
+	}
+
+	start := date.Add(-maxStaleness)
+	end := date
+	if opts.PreferNext {
+		end = date.Add(maxStaleness)
+	}
+
+	var rates []models.Rate
+	var err error
+
+	isHistorical := !opts.KnownAt.IsZero()
+	if isHistorical {
+		rates, err = r.GetRatesBetweenKnownAt(ctx, start, end, base, []string{target}, opts.KnownAt)
+	} else {
+		rates, err = r.GetRatesBetween(ctx, start, end, base, []string{target})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying rate as of %s: %w", date.Format("2006-01-02"), err)
+	}
+
+	chosen, found := latestOnOrBefore(rates, date)
+	if opts.PreferNext {
+		if next, hasNext := earliestOnOrAfter(rates, date); hasNext {
+			chosen, found = next, true
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	result := &AsOfResult{
+		Rate:          chosen,
+		RequestedDate: date,
+		EffectiveDate: chosen.Date,
+	}
+
+	isSubstitute := !sameDay(chosen.Date, date)
+	if isSubstitute {
+		result.Reason = explainMissing(chosen, date)
+	}
+
+	return result, nil
+}
+
+// explainMissing asks the calendars of the sources behind a rate why they
+// didn't publish on the date
+func explainMissing(rate models.Rate, date time.Time) string {
+	for _, source := range strings.Split(rate.Source, "+") {
+		if reason := calendar.ExplainSource(source, date); reason != "" {
+			return reason
+		}
+	}
+
+	return fmt.Sprintf("no %s/%s rate stored for %s", rate.Base, rate.Target, date.Format("2006-01-02"))
+}
+
+func sameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+// rates come ordered by date ascending
+func latestOnOrBefore(rates []models.Rate, date time.Time) (models.Rate, bool) {
+	for i := len(rates) - 1; i >= 0; i-- {
+		if !rates[i].Date.After(date) {
+			return rates[i], true
+		}
+	}
+	return models.Rate{}, false
+}
+
+func earliestOnOrAfter(rates []models.Rate, date time.Time) (models.Rate, bool) {
+	for _, rate := range rates {
+		if !rate.Date.Before(date) {
+			return rate, true
+		}
+	}
+	return models.Rate{}, false
+}
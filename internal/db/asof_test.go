@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestGetRateAsOf(t *testing.T) {
+	forEachStore(t, testGetRateAsOf)
+}
+
+func testGetRateAsOf(t *testing.T, database Store) {
+	ctx := context.Background()
+
+	friday := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+	saturday := friday.AddDate(0, 0, 1)
+	monday := friday.AddDate(0, 0, 3)
+
+	err := database.InsertRates(ctx, []models.Rate{
+		{Date: friday, Base: "EUR", Target: "USD", Value: 1.16, Source: "ECB", Fetched: time.Now()},
+		{Date: monday, Base: "EUR", Target: "USD", Value: 1.17, Source: "ECB", Fetched: time.Now()},
+		// a later row for another pair must not shadow the requested one
+		{Date: saturday, Base: "USD", Target: "XAF", Value: 560, Source: "BIS", Fetched: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		date time.Time
+		opts AsOfOptions
+		want time.Time
+	}{
+		{"weekend maps to previous", saturday, AsOfOptions{}, friday},
+		{"weekend maps to next", saturday, AsOfOptions{PreferNext: true}, monday},
+		{"exact date", monday, AsOfOptions{PreferNext: true}, monday},
+	}
+
+	for _, tc := range cases {
+		result, err := database.GetRateAsOf(ctx, "USD", "EUR", tc.date, tc.opts)
+		if err != nil || result == nil {
+			t.Fatalf("%s: got %v, %v", tc.name, result, err)
+		}
+
+		correctEffective := result.EffectiveDate.Equal(tc.want)
+		correctRequested := result.RequestedDate.Equal(tc.date)
+		if !correctEffective || !correctRequested {
+			t.Errorf("%s: got %+v", tc.name, result)
+		}
+	}
+
+	weekend, err := database.GetRateAsOf(ctx, "USD", "EUR", saturday, AsOfOptions{})
+	if err != nil || weekend == nil {
+		t.Fatalf("got %v, %v", weekend, err)
+	}
+
+	if weekend.Reason != "2025-10-18 is not a business day for TARGET2 (Saturday)" {
+		t.Errorf("unexpected reason: %q", weekend.Reason)
+	}
+
+	tooStale, err := database.GetRateAsOf(ctx, "USD", "EUR", monday.AddDate(0, 0, 10), AsOfOptions{MaxStaleness: 5 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tooStale != nil {
+		t.Errorf("expected no rate beyond max staleness, got %+v", tooStale)
+	}
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// ReplaceCurrencies swaps a source's whole catalogue, so currencies that
+// disappeared upstream don't linger
+func (db *DB) ReplaceCurrencies(ctx context.Context, source string, infos []models.CurrencyInfo) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `delete from currencies where source = ?`, source); err != nil {
+		return fmt.Errorf("clearing currencies: %w", err)
+	}
+
+	insertQuery := `
+		insert into currencies (source, currency, first_date, last_date, active, refreshed_at)
+		values (?, ?, ?, ?, ?, ?)
+	`
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, info := range infos {
+		_, err := stmt.ExecContext(ctx,
+			source,
+			info.Currency,
+			nullableDate(info.FirstDate),
+			nullableDate(info.LastDate),
+			info.Active,
+			info.Refreshed,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting currency: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (db *DB) GetCurrencies(ctx context.Context, source string) ([]models.CurrencyInfo, error) {
+	query := `
+		select source, currency, first_date, last_date, active, refreshed_at
+		from   currencies
+		where  source = ?
+		order by currency
+	`
+
+	rows, err := db.QueryContext(ctx, query, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying currencies: %w", err)
+	}
+	defer rows.Close()
+
+	infos, err := scanCurrencies(rows)
+	if err != nil {
+		return nil, fmt.Errorf("querying currencies: %w", err)
+	}
+
+	return infos, nil
+}
+
+func scanCurrencies(rows *sql.Rows) ([]models.CurrencyInfo, error) {
+	var infos []models.CurrencyInfo
+	for rows.Next() {
+		var info models.CurrencyInfo
+		var firstDate, lastDate sql.NullTime
+		var activeInt int
+
+		err := rows.Scan(
+			&info.Source,
+			&info.Currency,
+			&firstDate,
+			&lastDate,
+			&activeInt,
+			&info.Refreshed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning currency: %w", err)
+		}
+
+		info.FirstDate = firstDate.Time
+		info.LastDate = lastDate.Time
+		info.Active = (activeInt != 0)
+		infos = append(infos, info)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+func nullableDate(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -2,22 +2,60 @@ package db
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"time"
 
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
 )
 
+// DB is the SQLite store
 type DB struct {
 	*sql.DB
+	rateReader
 }
 
-func Open(path string) (*DB, error) {
-	const pragmas = "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+// the pragmas used when Open is given no options
+const (
+	DefaultJournalMode = "wal"
+	DefaultBusyTimeout = 5 * time.Second
+)
+
+type options struct {
+	journalMode string
+	busyTimeout time.Duration
+}
 
-	dsn := path + pragmas
+type Option func(*options)
+
+func WithJournalMode(mode string) Option {
+	return func(o *options) {
+		o.journalMode = mode
+	}
+}
+
+// WithBusyTimeout sets how long a write waits on a locked database before failing
+func WithBusyTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.busyTimeout = timeout
+	}
+}
 
-	sqlDB, err := sql.Open("sqlite3", dsn)
+func Open(path string, opts ...Option) (*DB, error) {
+	o := options{journalMode: DefaultJournalMode, busyTimeout: DefaultBusyTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if err := renameLegacyFile(path); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := sql.Open("sqlite3", dataSourceName(path, o))
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
@@ -27,6 +65,7 @@ func Open(path string) (*DB, error) {
 	}
 
 	db := &DB{DB: sqlDB}
+	db.rateReader = rateReader{backend: db}
 
 	if err := db.migrate(); err != nil {
 		sqlDB.Close()
@@ -36,8 +75,37 @@ func Open(path string) (*DB, error) {
 	return db, nil
 }
 
-func (db *DB) migrate() error {
-	schema := `
+// dataSourceName builds a file: uri, the driver ignores _pragma on plain paths
+func dataSourceName(path string, o options) string {
+	query := url.Values{}
+	query.Add("_pragma", fmt.Sprintf("journal_mode(%s)", o.journalMode))
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", o.busyTimeout.Milliseconds()))
+
+	dsn := url.URL{Scheme: "file", OmitHost: true, Path: path, RawQuery: query.Encode()}
+	return dsn.String()
+}
+
+// renameLegacyFile moves a database created by earlier versions, which glued
+// the pragmas onto the plain path and so stored it under that whole name
+func renameLegacyFile(path string) error {
+	legacy := path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)"
+
+	if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return nil
+	}
+
+	if err := os.Rename(legacy, path); err != nil {
+		return fmt.Errorf("renaming legacy database file: %w", err)
+	}
+	return nil
+}
+
+// migrations are applied in order, the applied count is tracked in pragma user_version
+var migrations = []string{
+	`
 		create table if not exists rates (
 			date        date      not null,
 			base        text      not null,
@@ -52,11 +120,132 @@ func (db *DB) migrate() error {
 		create index if not exists idx_rates_date      on rates(date);
 		create index if not exists idx_rates_base_date on rates(base, date);
 		create index if not exists idx_rates_source    on rates(source);
+	`,
 	`
+		alter table rates add column frequency text not null default 'D';
+	`,
+	`
+		create table if not exists currencies (
+			source        text      not null,
+			currency      text      not null,
+			first_date    date,
+			last_date     date,
+			active        integer   not null default 1,
+			refreshed_at  timestamp not null,
+			primary key (source, currency)
+		);
+	`,
+	// derived rates are computed on read, so only native observations are kept
+	// and the same pair may now come from several sources
+	`
+		create table rates_native (
+			date        date      not null,
+			base        text      not null,
+			target      text      not null,
+			rate        real      not null,
+			source      text      not null,
+			fetched_at  timestamp not null,
+			frequency   text      not null default 'D',
+			primary key (date, base, target, source)
+		);
+
+		insert into rates_native (date, base, target, rate, source, fetched_at, frequency)
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rates
+		where  calculated = 0;
+
+		drop table rates;
+		alter table rates_native rename to rates;
+
+		create index idx_rates_date      on rates(date);
+		create index idx_rates_base_date on rates(base, date);
+		create index idx_rates_source    on rates(source);
+	`,
+	`
+		create table if not exists quarantine (
+			id              integer   primary key,
+			date            date      not null,
+			base            text      not null,
+			target          text      not null,
+			rate            real      not null,
+			source          text      not null,
+			fetched_at      timestamp not null,
+			frequency       text      not null default 'D',
+			reason          text      not null,
+			status          text      not null default 'pending',
+			quarantined_at  timestamp not null,
+			reviewed_at     timestamp
+		);
+
+		create index if not exists idx_quarantine_status on quarantine(status);
+	`,
+	// rates keeps the current value, every distinct value ever stored is kept
+	// in rate_revisions with the time it was fetched
+	`
+		create table if not exists rate_revisions (
+			id          integer   primary key,
+			date        date      not null,
+			base        text      not null,
+			target      text      not null,
+			rate        real      not null,
+			source      text      not null,
+			fetched_at  timestamp not null,
+			frequency   text      not null default 'D'
+		);
+
+		create index if not exists idx_revisions_pair on rate_revisions(date, base, target, source);
 
-	if _, err := db.Exec(schema); err != nil {
-		return fmt.Errorf("creating schema: %w", err)
+		insert into rate_revisions (date, base, target, rate, source, fetched_at, frequency)
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rates;
+
+		create trigger if not exists rates_revision_insert
+		after insert on rates
+		begin
+			insert into rate_revisions (date, base, target, rate, source, fetched_at, frequency)
+			values (new.date, new.base, new.target, new.rate, new.source, new.fetched_at, new.frequency);
+		end;
+
+		create trigger if not exists rates_revision_update
+		after update of rate on rates
+		when old.rate != new.rate
+		begin
+			insert into rate_revisions (date, base, target, rate, source, fetched_at, frequency)
+			values (new.date, new.base, new.target, new.rate, new.source, new.fetched_at, new.frequency);
+		end;
+	`,
+}
+
+func (db *DB) migrate() error {
+	var version int
+	if err := db.QueryRow("pragma user_version").Scan(&version); err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		if err := db.applyMigration(i+1, migrations[i]); err != nil {
+			return fmt.Errorf("applying migration %d: %w", i+1, err)
+		}
 	}
 
 	return nil
 }
+
+func (db *DB) applyMigration(version int, schema string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("updating schema: %w", err)
+	}
+
+	// pragmas don't accept placeholders
+	if _, err := tx.Exec(fmt.Sprintf("pragma user_version = %d", version)); err != nil {
+		return fmt.Errorf("setting schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
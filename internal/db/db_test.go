@@ -0,0 +1,66 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenAppliesPragmas(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fxgo.db")
+
+	database, err := Open(path, WithBusyTimeout(2*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	var journalMode string
+	var busyTimeout int
+	if err := database.QueryRow("pragma journal_mode").Scan(&journalMode); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.QueryRow("pragma busy_timeout").Scan(&busyTimeout); err != nil {
+		t.Fatal(err)
+	}
+
+	correctPragmas := (journalMode == "wal" && busyTimeout == 2000)
+	if !correctPragmas {
+		t.Errorf("got journal_mode %s and busy_timeout %d, want wal and 2000", journalMode, busyTimeout)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database not stored at its path: %v", err)
+	}
+}
+
+func TestOpenRenamesLegacyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "fxgo.db")
+
+	// create the database the way earlier versions named it
+	legacy, err := Open(path + "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := legacy.Exec("pragma user_version = 42"); err != nil {
+		t.Fatal(err)
+	}
+	legacy.Close()
+
+	database, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	var version int
+	if err := database.QueryRow("pragma user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != 42 {
+		t.Errorf("legacy database not picked up, user_version is %d", version)
+	}
+}
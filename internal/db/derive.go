@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/triangulate"
+)
+
+// rateReader answers every derived read from a backend's direct rates, so
+// the stores only have to store and scan
+type rateReader struct {
+	backend backend
+	// sources from most to least authoritative, used when deriving rates
+	priority []string
+}
+
+// SetSourcePriority orders sources from most to least authoritative,
+// derived rates prefer paths through higher ranked sources
+func (r *rateReader) SetSourcePriority(sources []string) {
+	r.priority = sources
+}
+
+// GetDirectRatesForDate returns every stored observation for a date across all sources
+func (r *rateReader) GetDirectRatesForDate(ctx context.Context, date time.Time) ([]models.Rate, error) {
+	return r.backend.GetDirectRatesBetween(ctx, date, date, "")
+}
+
+// GetRate returns the stored rate, or one derived from the direct rates of that day
+func (r *rateReader) GetRate(ctx context.Context, date time.Time, base, target string) (*models.Rate, error) {
+	direct, err := r.GetDirectRatesForDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("querying rate: %w", err)
+	}
+
+	rates := r.deriveRates(direct, base, []string{target})
+
+	notFound := (len(rates) == 0)
+	if notFound {
+		return nil, nil
+	}
+
+	return &rates[0], nil
+}
+
+// GetLatestRate returns the most recent rate for the pair, derived like
+// GetLatestRates. A pair older than the lookback falls back to its last stored
+// rate in either direction, a stored target/base rate comes back inverted and marked Calculated
+func (r *rateReader) GetLatestRate(ctx context.Context, base, target string) (*models.Rate, error) {
+	derived, err := r.GetLatestRates(ctx, base, []string{target})
+	if err != nil {
+		return nil, err
+	}
+
+	isDerived := (len(derived) > 0)
+	if isDerived {
+		return &derived[0], nil
+	}
+
+	rate, err := r.backend.latestEitherWay(ctx, base, target)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest rate: %w", err)
+	}
+
+	isInverse := (rate != nil && rate.Base != base)
+	if isInverse {
+		inverted := invertRate(*rate)
+		return &inverted, nil
+	}
+
+	return rate, nil
+}
+
+func (r *rateReader) GetRatesForDate(ctx context.Context, date time.Time, base string, targets []string) ([]models.Rate, error) {
+	if len(targets) == 0 {
+		return []models.Rate{}, nil
+	}
+
+	direct, err := r.GetDirectRatesForDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("querying rates: %w", err)
+	}
+
+	return r.deriveRates(direct, base, targets), nil
+}
+
+// how many of the most recent dates GetLatestRates walks back through
+// looking for a target, covers a month of daily publications
+const latestLookback = 31
+
+func (r *rateReader) GetLatestRates(ctx context.Context, base string, targets []string) ([]models.Rate, error) {
+	if len(targets) == 0 {
+		return []models.Rate{}, nil
+	}
+
+	dates, err := r.backend.latestDates(ctx, latestLookback)
+	if err != nil {
+		return nil, fmt.Errorf("querying latest dates: %w", err)
+	}
+
+	// walk back day by day, each target takes the most recent day it can be derived on
+	remaining := targets
+	var rates []models.Rate
+
+	for _, date := range dates {
+		noneLeft := (len(remaining) == 0)
+		if noneLeft {
+			break
+		}
+
+		direct, err := r.GetDirectRatesForDate(ctx, date)
+		if err != nil {
+			return nil, fmt.Errorf("querying latest rates: %w", err)
+		}
+
+		found := r.deriveRates(direct, base, remaining)
+		rates = append(rates, found...)
+		remaining = withoutTargets(remaining, found)
+	}
+
+	return rates, nil
+}
+
+func (r *rateReader) GetRatesBetween(ctx context.Context, startDate, endDate time.Time, base string, targets []string) ([]models.Rate, error) {
+	if len(targets) == 0 {
+		return []models.Rate{}, nil
+	}
+
+	direct, err := r.backend.GetDirectRatesBetween(ctx, startDate, endDate, "")
+	if err != nil {
+		return nil, fmt.Errorf("querying rates between dates: %w", err)
+	}
+
+	return r.deriveByDay(direct, base, targets), nil
+}
+
+// GetDirectRatesKnownAt is GetDirectRatesForDate as the store stood at knownAt
+func (r *rateReader) GetDirectRatesKnownAt(ctx context.Context, date, knownAt time.Time) ([]models.Rate, error) {
+	rates, err := r.backend.directRatesKnownAt(ctx, date, date, knownAt)
+	if err != nil {
+		return nil, fmt.Errorf("querying rates known at %s: %w", knownAt.Format(time.RFC3339), err)
+	}
+
+	return rates, nil
+}
+
+// GetRateKnownAt answers like GetRate did at knownAt, before any later
+// revisions or newly fetched sources
+func (r *rateReader) GetRateKnownAt(ctx context.Context, date time.Time, base, target string, knownAt time.Time) (*models.Rate, error) {
+	direct, err := r.GetDirectRatesKnownAt(ctx, date, knownAt)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := r.deriveRates(direct, base, []string{target})
+
+	notFound := (len(rates) == 0)
+	if notFound {
+		return nil, nil
+	}
+
+	return &rates[0], nil
+}
+
+// GetRatesBetweenKnownAt is GetRatesBetween as the store stood at knownAt
+func (r *rateReader) GetRatesBetweenKnownAt(ctx context.Context, startDate, endDate time.Time, base string, targets []string, knownAt time.Time) ([]models.Rate, error) {
+	if len(targets) == 0 {
+		return []models.Rate{}, nil
+	}
+
+	direct, err := r.backend.directRatesKnownAt(ctx, startDate, endDate, knownAt)
+	if err != nil {
+		return nil, fmt.Errorf("querying rates known at %s: %w", knownAt.Format(time.RFC3339), err)
+	}
+
+	return r.deriveByDay(direct, base, targets), nil
+}
+
+// deriveRates answers base/target for every target from one day's direct rates,
+// stored pairs come back as-is while inverses and crosses are marked Calculated
+func (r *rateReader) deriveRates(direct []models.Rate, base string, targets []string) []models.Rate {
+	noData := (len(direct) == 0)
+	if noData {
+		return nil
+	}
+
+	g := triangulate.NewGraph(direct, r.priority)
+
+	var rates []models.Rate
+	for _, target := range targets {
+		result, err := g.Convert(base, target)
+		if err != nil {
+			continue
+		}
+
+		rate := result.Rate
+		if rate.Calculated {
+			rate.Derivation = result.Path
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates
+}
+
+// deriveByDay answers base/targets on every day of date-ordered direct rates
+func (r *rateReader) deriveByDay(direct []models.Rate, base string, targets []string) []models.Rate {
+	// rows are ordered by date, so each day is a contiguous run
+	var rates []models.Rate
+	for start := 0; start < len(direct); {
+		end := start
+		for end < len(direct) && direct[end].Date.Equal(direct[start].Date) {
+			end++
+		}
+
+		rates = append(rates, r.deriveRates(direct[start:end], base, targets)...)
+		start = end
+	}
+
+	return rates
+}
+
+func withoutTargets(targets []string, found []models.Rate) []string {
+	return slices.DeleteFunc(slices.Clone(targets), func(target string) bool {
+		return slices.ContainsFunc(found, func(rate models.Rate) bool {
+			return rate.Target == target
+		})
+	})
+}
+
+// invertRate turns a stored target/base rate into the requested base/target
+func invertRate(stored models.Rate) models.Rate {
+	return models.Rate{
+		Base:       stored.Target,
+		Target:     stored.Base,
+		Value:      1 / stored.Value,
+		Date:       stored.Date,
+		Source:     stored.Source,
+		Fetched:    stored.Fetched,
+		Calculated: true,
+		Frequency:  stored.Frequency,
+		Derivation: []models.Hop{{
+			From:     stored.Target,
+			To:       stored.Base,
+			Value:    1 / stored.Value,
+			Source:   stored.Source,
+			Date:     stored.Date,
+			Inverted: true,
+		}},
+	}
+}
@@ -0,0 +1,369 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// Memory is a Store kept in process memory, for tests and embedding.
+// It behaves like the SQLite store, revisions and quarantine included.
+type Memory struct {
+	rateReader
+
+	mu         sync.RWMutex
+	rates      map[rateKey]models.Rate
+	revisions  []models.Rate
+	currencies map[string][]models.CurrencyInfo
+	quarantine []models.QuarantinedRate
+}
+
+type rateKey struct {
+	date                 string
+	base, target, source string
+}
+
+func NewMemory() *Memory {
+	m := &Memory{
+		rates:      make(map[rateKey]models.Rate),
+		currencies: make(map[string][]models.CurrencyInfo),
+	}
+	m.rateReader = rateReader{backend: m}
+	return m
+}
+
+func keyOf(rate models.Rate) rateKey {
+	return rateKey{rate.Date.Format("2006-01-02"), rate.Base, rate.Target, rate.Source}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) InsertRate(ctx context.Context, rate models.Rate) error {
+	return m.InsertRates(ctx, []models.Rate{rate})
+}
+
+func (m *Memory) InsertRates(ctx context.Context, rates []models.Rate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, rate := range rates {
+		if rate.Calculated {
+			continue
+		}
+		m.upsert(rate)
+	}
+
+	return nil
+}
+
+// upsert mirrors the SQLite upsert and its revision triggers, callers hold the lock
+func (m *Memory) upsert(rate models.Rate) {
+	rate.Derivation = nil
+	rate.Frequency = frequencyOf(rate)
+
+	key := keyOf(rate)
+	previous, exists := m.rates[key]
+	m.rates[key] = rate
+
+	isRevision := (!exists || previous.Value != rate.Value)
+	if isRevision {
+		m.revisions = append(m.revisions, rate)
+	}
+}
+
+func (m *Memory) GetDirectRatesBetween(ctx context.Context, startDate, endDate time.Time, source string) ([]models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var rates []models.Rate
+	for _, rate := range m.rates {
+		inRange := !rate.Date.Before(startDate) && !rate.Date.After(endDate)
+		isSource := (source == "" || rate.Source == source)
+		if inRange && isSource {
+			rates = append(rates, rate)
+		}
+	}
+
+	sortDirect(rates)
+	return rates, nil
+}
+
+func (m *Memory) directRatesKnownAt(ctx context.Context, startDate, endDate, knownAt time.Time) ([]models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// revisions are appended in insertion order, later ones win on equal fetch times
+	latest := make(map[rateKey]models.Rate)
+	for _, rate := range m.revisions {
+		inRange := !rate.Date.Before(startDate) && !rate.Date.After(endDate)
+		isKnown := !rate.Fetched.After(knownAt)
+		if !inRange || !isKnown {
+			continue
+		}
+
+		key := keyOf(rate)
+		if current, ok := latest[key]; ok && current.Fetched.After(rate.Fetched) {
+			continue
+		}
+		latest[key] = rate
+	}
+
+	var rates []models.Rate
+	for _, rate := range latest {
+		rates = append(rates, rate)
+	}
+
+	sortDirect(rates)
+	return rates, nil
+}
+
+func (m *Memory) latestDates(ctx context.Context, limit int) ([]time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var dates []time.Time
+	for key, rate := range m.rates {
+		if !seen[key.date] {
+			seen[key.date] = true
+			dates = append(dates, rate.Date)
+		}
+	}
+
+	slices.SortFunc(dates, func(a, b time.Time) int { return b.Compare(a) })
+	if len(dates) > limit {
+		dates = dates[:limit]
+	}
+	return dates, nil
+}
+
+func (m *Memory) latestEitherWay(ctx context.Context, base, target string) (*models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var best *models.Rate
+	for _, rate := range m.rates {
+		isDirect := (rate.Base == base && rate.Target == target)
+		isInverse := (rate.Base == target && rate.Target == base)
+		if !isDirect && !isInverse {
+			continue
+		}
+
+		isNewer := (best == nil || rate.Date.After(best.Date))
+		isPreferred := (best != nil && rate.Date.Equal(best.Date) && isDirect && best.Base != base)
+		if isNewer || isPreferred {
+			found := rate
+			best = &found
+		}
+	}
+
+	return best, nil
+}
+
+func (m *Memory) GetPairHistory(ctx context.Context, base, target, source string, before time.Time, limit int) ([]models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var rates []models.Rate
+	for _, rate := range m.rates {
+		isPair := (rate.Base == base && rate.Target == target && rate.Source == source)
+		if isPair && rate.Date.Before(before) {
+			rates = append(rates, rate)
+		}
+	}
+
+	slices.SortFunc(rates, func(a, b models.Rate) int { return a.Date.Compare(b.Date) })
+	if len(rates) > limit {
+		rates = rates[len(rates)-limit:]
+	}
+	return rates, nil
+}
+
+func (m *Memory) GetNearestDate(ctx context.Context, date time.Time) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var nearest time.Time
+	for _, rate := range m.rates {
+		isCandidate := !rate.Date.After(date) && rate.Date.After(nearest)
+		if isCandidate {
+			nearest = rate.Date
+		}
+	}
+
+	if nearest.IsZero() {
+		return time.Time{}, fmt.Errorf("no rates found before %s", date)
+	}
+	return nearest, nil
+}
+
+func (m *Memory) GetDateRange(ctx context.Context) (time.Time, time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var minDate, maxDate time.Time
+	for _, rate := range m.rates {
+		if minDate.IsZero() || rate.Date.Before(minDate) {
+			minDate = rate.Date
+		}
+		if rate.Date.After(maxDate) {
+			maxDate = rate.Date
+		}
+	}
+
+	return minDate, maxDate, nil
+}
+
+func (m *Memory) GetAvailableCurrencies(ctx context.Context) ([]string, error) {
+	return m.distinct(func(rate models.Rate) string { return rate.Target }), nil
+}
+
+func (m *Memory) GetAvailableBases(ctx context.Context) ([]string, error) {
+	return m.distinct(func(rate models.Rate) string { return rate.Base }), nil
+}
+
+func (m *Memory) distinct(field func(models.Rate) string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var values []string
+	for _, rate := range m.rates {
+		value := field(rate)
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	slices.Sort(values)
+	return values
+}
+
+func (m *Memory) ReplaceCurrencies(ctx context.Context, source string, infos []models.CurrencyInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := make([]models.CurrencyInfo, len(infos))
+	for i, info := range infos {
+		info.Source = source
+		stored[i] = info
+	}
+
+	slices.SortFunc(stored, func(a, b models.CurrencyInfo) int { return cmp.Compare(a.Currency, b.Currency) })
+	m.currencies[source] = stored
+	return nil
+}
+
+func (m *Memory) GetCurrencies(ctx context.Context, source string) ([]models.CurrencyInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.currencies[source]), nil
+}
+
+func (m *Memory) GetRevisions(ctx context.Context, date time.Time, base, target, source string) ([]models.Rate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var revisions []models.Rate
+	for _, rate := range m.revisions {
+		isPair := (rate.Base == base && rate.Target == target && sameDay(rate.Date, date))
+		isSource := (source == "" || rate.Source == source)
+		if isPair && isSource {
+			revisions = append(revisions, rate)
+		}
+	}
+
+	slices.SortStableFunc(revisions, func(a, b models.Rate) int {
+		if a.Source != b.Source {
+			return cmp.Compare(a.Source, b.Source)
+		}
+		return a.Fetched.Compare(b.Fetched)
+	})
+	return revisions, nil
+}
+
+func (m *Memory) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rate.Frequency = frequencyOf(rate)
+	m.quarantine = append(m.quarantine, models.QuarantinedRate{
+		ID:          int64(len(m.quarantine) + 1),
+		Rate:        rate,
+		Reason:      reason,
+		Status:      models.QuarantinePending,
+		Quarantined: time.Now(),
+	})
+	return nil
+}
+
+func (m *Memory) GetQuarantined(ctx context.Context, status string) ([]models.QuarantinedRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var result []models.QuarantinedRate
+	for _, q := range m.quarantine {
+		if status == "" || q.Status == status {
+			result = append(result, q)
+		}
+	}
+
+	slices.SortStableFunc(result, func(a, b models.QuarantinedRate) int { return a.Rate.Date.Compare(b.Rate.Date) })
+	return result, nil
+}
+
+func (m *Memory) ApproveQuarantined(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, err := m.review(id, models.QuarantineApproved)
+	if err != nil {
+		return err
+	}
+
+	m.upsert(q.Rate)
+	return nil
+}
+
+func (m *Memory) RejectQuarantined(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.review(id, models.QuarantineRejected)
+	return err
+}
+
+// review moves a pending rate to status, callers hold the lock
+func (m *Memory) review(id int64, status string) (models.QuarantinedRate, error) {
+	for i, q := range m.quarantine {
+		isPending := (q.ID == id && q.Status == models.QuarantinePending)
+		if !isPending {
+			continue
+		}
+
+		m.quarantine[i].Status = status
+		m.quarantine[i].Reviewed = time.Now()
+		return m.quarantine[i], nil
+	}
+
+	return models.QuarantinedRate{}, fmt.Errorf("no pending quarantined rate with id %d", id)
+}
+
+// sortDirect orders rates like the SQL stores: date, source, base, target
+func sortDirect(rates []models.Rate) {
+	slices.SortFunc(rates, func(a, b models.Rate) int {
+		return cmp.Or(
+			a.Date.Compare(b.Date),
+			cmp.Compare(a.Source, b.Source),
+			cmp.Compare(a.Base, b.Base),
+			cmp.Compare(a.Target, b.Target),
+		)
+	})
+}
@@ -0,0 +1,546 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// Postgres is the PostgreSQL store. fxgo doesn't pick a driver, callers open
+// the *sql.DB with theirs (pgx's stdlib, lib/pq) and hand it to OpenPostgres.
+type Postgres struct {
+	*sql.DB
+	rateReader
+}
+
+// postgresMigrations mirror the SQLite schema as it stands, tracked in fxgo_schema
+var postgresMigrations = []string{
+	`
+		create table if not exists rates (
+			date        date             not null,
+			base        text             not null,
+			target      text             not null,
+			rate        double precision not null,
+			source      text             not null,
+			fetched_at  timestamptz      not null,
+			frequency   text             not null default 'D',
+			primary key (date, base, target, source)
+		);
+
+		create index if not exists idx_rates_base_date on rates(base, date);
+		create index if not exists idx_rates_source    on rates(source);
+
+		create table if not exists rate_revisions (
+			id          bigserial        primary key,
+			date        date             not null,
+			base        text             not null,
+			target      text             not null,
+			rate        double precision not null,
+			source      text             not null,
+			fetched_at  timestamptz      not null,
+			frequency   text             not null default 'D'
+		);
+
+		create index if not exists idx_revisions_pair on rate_revisions(date, base, target, source);
+
+		create or replace function record_rate_revision() returns trigger as $$
+		begin
+			if tg_op = 'INSERT' or old.rate is distinct from new.rate then
+				insert into rate_revisions (date, base, target, rate, source, fetched_at, frequency)
+				values (new.date, new.base, new.target, new.rate, new.source, new.fetched_at, new.frequency);
+			end if;
+			return new;
+		end;
+		$$ language plpgsql;
+
+		drop trigger if exists rates_revision on rates;
+		create trigger rates_revision
+		after insert or update on rates
+		for each row execute function record_rate_revision();
+
+		create table if not exists currencies (
+			source        text        not null,
+			currency      text        not null,
+			first_date    date,
+			last_date     date,
+			active        boolean     not null default true,
+			refreshed_at  timestamptz not null,
+			primary key (source, currency)
+		);
+
+		create table if not exists quarantine (
+			id              bigserial        primary key,
+			date            date             not null,
+			base            text             not null,
+			target          text             not null,
+			rate            double precision not null,
+			source          text             not null,
+			fetched_at      timestamptz      not null,
+			frequency       text             not null default 'D',
+			reason          text             not null,
+			status          text             not null default 'pending',
+			quarantined_at  timestamptz      not null,
+			reviewed_at     timestamptz
+		);
+
+		create index if not exists idx_quarantine_status on quarantine(status);
+	`,
+}
+
+const postgresUpsertQuery = `
+	insert into rates (date, base, target, rate, source, fetched_at, frequency)
+	values ($1, $2, $3, $4, $5, $6, $7)
+	on conflict (date, base, target, source) do update set
+		rate       = excluded.rate,
+		fetched_at = excluded.fetched_at,
+		frequency  = excluded.frequency
+`
+
+const postgresKnownAtQuery = `
+	select distinct on (date, base, target, source)
+	       date, base, target, rate, source, fetched_at, frequency
+	from   rate_revisions
+	where  date >= $1::date and date <= $2::date and fetched_at <= $3
+	order by date asc, base asc, target asc, source asc, fetched_at desc, id desc
+`
+
+func OpenPostgres(sqlDB *sql.DB) (*Postgres, error) {
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("pinging database: %w", err)
+	}
+
+	db := &Postgres{DB: sqlDB}
+	db.rateReader = rateReader{backend: db}
+
+	if err := db.migrate(); err != nil {
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+
+	return db, nil
+}
+
+func (db *Postgres) migrate() error {
+	if _, err := db.Exec(`create table if not exists fxgo_schema (version integer not null)`); err != nil {
+		return fmt.Errorf("creating schema table: %w", err)
+	}
+
+	var version int
+	err := db.QueryRow(`select coalesce(max(version), 0) from fxgo_schema`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+
+	for i := version; i < len(postgresMigrations); i++ {
+		if err := db.applyMigration(i+1, postgresMigrations[i]); err != nil {
+			return fmt.Errorf("applying migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (db *Postgres) applyMigration(version int, schema string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(schema); err != nil {
+		return fmt.Errorf("updating schema: %w", err)
+	}
+
+	if _, err := tx.Exec(`insert into fxgo_schema (version) values ($1)`, version); err != nil {
+		return fmt.Errorf("setting schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func (db *Postgres) InsertRate(ctx context.Context, rate models.Rate) error {
+	return db.InsertRates(ctx, []models.Rate{rate})
+}
+
+func (db *Postgres) InsertRates(ctx context.Context, rates []models.Rate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, postgresUpsertQuery)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if rate.Calculated {
+			continue
+		}
+
+		_, err := stmt.ExecContext(ctx,
+			rate.Date,
+			rate.Base,
+			rate.Target,
+			rate.Value,
+			rate.Source,
+			rate.Fetched,
+			frequencyOf(rate),
+		)
+		if err != nil {
+			return fmt.Errorf("inserting rate: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Postgres) GetDirectRatesBetween(ctx context.Context, startDate, endDate time.Time, source string) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rates
+		where  date >= $1::date and date <= $2::date and ($3 = '' or source = $3)
+		order by date asc, source asc, base asc, target asc
+	`
+
+	rates, err := scanMultipleRates(ctx, db, query, startDate, endDate, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying direct rates between dates: %w", err)
+	}
+
+	return rates, nil
+}
+
+func (db *Postgres) directRatesKnownAt(ctx context.Context, startDate, endDate, knownAt time.Time) ([]models.Rate, error) {
+	rates, err := scanMultipleRates(ctx, db, postgresKnownAtQuery, startDate, endDate, knownAt)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDirect(rates)
+	return rates, nil
+}
+
+func (db *Postgres) latestDates(ctx context.Context, limit int) ([]time.Time, error) {
+	query := `
+		select distinct date
+		from   rates
+		order by date desc
+		limit  $1
+	`
+
+	rows, err := db.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+
+	return dates, rows.Err()
+}
+
+func (db *Postgres) latestEitherWay(ctx context.Context, base, target string) (*models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rates
+		where  (base = $1 and target = $2) or (base = $2 and target = $1)
+		order by date desc, (base = $1) desc
+		limit  1
+	`
+
+	return scanSingleRate(ctx, db, query, base, target)
+}
+
+func (db *Postgres) GetPairHistory(ctx context.Context, base, target, source string, before time.Time, limit int) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from (
+			select date, base, target, rate, source, fetched_at, frequency
+			from   rates
+			where  base = $1 and target = $2 and source = $3 and date < $4::date
+			order by date desc
+			limit  $5
+		) history
+		order by date asc
+	`
+
+	rates, err := scanMultipleRates(ctx, db, query, base, target, source, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying pair history: %w", err)
+	}
+
+	return rates, nil
+}
+
+func (db *Postgres) GetNearestDate(ctx context.Context, date time.Time) (time.Time, error) {
+	query := `
+		select max(date)
+		from   rates
+		where  date <= $1::date
+	`
+
+	var nearestDate sql.NullTime
+	if err := db.QueryRowContext(ctx, query, date).Scan(&nearestDate); err != nil {
+		return time.Time{}, fmt.Errorf("querying nearest date: %w", err)
+	}
+
+	if !nearestDate.Valid {
+		return time.Time{}, fmt.Errorf("no rates found before %s", date)
+	}
+
+	return nearestDate.Time, nil
+}
+
+func (db *Postgres) GetDateRange(ctx context.Context) (time.Time, time.Time, error) {
+	query := `
+		select min(date), max(date)
+		from   rates
+	`
+
+	var minDate, maxDate sql.NullTime
+	if err := db.QueryRowContext(ctx, query).Scan(&minDate, &maxDate); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("querying date range: %w", err)
+	}
+
+	return minDate.Time, maxDate.Time, nil
+}
+
+func (db *Postgres) GetAvailableCurrencies(ctx context.Context) ([]string, error) {
+	currencies, err := scanStringList(ctx, db, `select distinct target from rates order by target`)
+	if err != nil {
+		return nil, fmt.Errorf("querying available currencies: %w", err)
+	}
+
+	return currencies, nil
+}
+
+func (db *Postgres) GetAvailableBases(ctx context.Context) ([]string, error) {
+	bases, err := scanStringList(ctx, db, `select distinct base from rates order by base`)
+	if err != nil {
+		return nil, fmt.Errorf("querying available bases: %w", err)
+	}
+
+	return bases, nil
+}
+
+func (db *Postgres) ReplaceCurrencies(ctx context.Context, source string, infos []models.CurrencyInfo) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `delete from currencies where source = $1`, source); err != nil {
+		return fmt.Errorf("clearing currencies: %w", err)
+	}
+
+	insertQuery := `
+		insert into currencies (source, currency, first_date, last_date, active, refreshed_at)
+		values ($1, $2, $3, $4, $5, $6)
+	`
+	stmt, err := tx.PrepareContext(ctx, insertQuery)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, info := range infos {
+		_, err := stmt.ExecContext(ctx,
+			source,
+			info.Currency,
+			nullableDate(info.FirstDate),
+			nullableDate(info.LastDate),
+			info.Active,
+			info.Refreshed,
+		)
+		if err != nil {
+			return fmt.Errorf("inserting currency: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Postgres) GetCurrencies(ctx context.Context, source string) ([]models.CurrencyInfo, error) {
+	query := `
+		select source, currency, first_date, last_date, active::int, refreshed_at
+		from   currencies
+		where  source = $1
+		order by currency
+	`
+
+	rows, err := db.QueryContext(ctx, query, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying currencies: %w", err)
+	}
+	defer rows.Close()
+
+	infos, err := scanCurrencies(rows)
+	if err != nil {
+		return nil, fmt.Errorf("querying currencies: %w", err)
+	}
+
+	return infos, nil
+}
+
+func (db *Postgres) GetRevisions(ctx context.Context, date time.Time, base, target, source string) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rate_revisions
+		where  date = $1::date and base = $2 and target = $3 and ($4 = '' or source = $4)
+		order by source asc, fetched_at asc, id asc
+	`
+
+	revisions, err := scanMultipleRates(ctx, db, query, date, base, target, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (db *Postgres) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	query := `
+		insert into quarantine (date, base, target, rate, source, fetched_at, frequency, reason, quarantined_at)
+		values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err := db.ExecContext(ctx, query,
+		rate.Date,
+		rate.Base,
+		rate.Target,
+		rate.Value,
+		rate.Source,
+		rate.Fetched,
+		frequencyOf(rate),
+		reason,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("quarantining rate: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Postgres) GetQuarantined(ctx context.Context, status string) ([]models.QuarantinedRate, error) {
+	query := `
+		select id, date, base, target, rate, source, fetched_at, frequency,
+		       reason, status, quarantined_at, reviewed_at
+		from   quarantine
+		where  ($1 = '' or status = $1)
+		order by date asc, id asc
+	`
+
+	rows, err := db.QueryContext(ctx, query, status)
+	if err != nil {
+		return nil, fmt.Errorf("querying quarantine: %w", err)
+	}
+	defer rows.Close()
+
+	result, err := scanQuarantined(rows)
+	if err != nil {
+		return nil, fmt.Errorf("querying quarantine: %w", err)
+	}
+
+	return result, nil
+}
+
+func (db *Postgres) ApproveQuarantined(ctx context.Context, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.review(ctx, tx, id, models.QuarantineApproved); err != nil {
+		return err
+	}
+
+	query := `
+		insert into rates (date, base, target, rate, source, fetched_at, frequency)
+		select date, base, target, rate, source, fetched_at, frequency
+		from   quarantine
+		where  id = $1
+		on conflict (date, base, target, source) do update set
+			rate       = excluded.rate,
+			fetched_at = excluded.fetched_at,
+			frequency  = excluded.frequency
+	`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("storing approved rate: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Postgres) RejectQuarantined(ctx context.Context, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := db.review(ctx, tx, id, models.QuarantineRejected); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (db *Postgres) review(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	query := `
+		update quarantine
+		set    status = $1, reviewed_at = $2
+		where  id = $3 and status = $4
+	`
+
+	result, err := tx.ExecContext(ctx, query, status, time.Now(), id, models.QuarantinePending)
+	if err != nil {
+		return fmt.Errorf("updating quarantine: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("updating quarantine: %w", err)
+	}
+
+	notPending := (affected == 0)
+	if notPending {
+		return fmt.Errorf("no pending quarantined rate with id %d", id)
+	}
+
+	return nil
+}
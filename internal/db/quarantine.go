@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func (db *DB) QuarantineRate(ctx context.Context, rate models.Rate, reason string) error {
+	query := `
+		insert into quarantine (date, base, target, rate, source, fetched_at, frequency, reason, quarantined_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.ExecContext(ctx, query,
+		rate.Date,
+		rate.Base,
+		rate.Target,
+		rate.Value,
+		rate.Source,
+		rate.Fetched,
+		frequencyOf(rate),
+		reason,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("quarantining rate: %w", err)
+	}
+
+	return nil
+}
+
+// GetQuarantined lists quarantined rates in a review state, or all of them if status is empty
+func (db *DB) GetQuarantined(ctx context.Context, status string) ([]models.QuarantinedRate, error) {
+	query := `
+		select id, date, base, target, rate, source, fetched_at, frequency,
+		       reason, status, quarantined_at, reviewed_at
+		from   quarantine
+		where  (? = '' or status = ?)
+		order by date asc, id asc
+	`
+
+	rows, err := db.QueryContext(ctx, query, status, status)
+	if err != nil {
+		return nil, fmt.Errorf("querying quarantine: %w", err)
+	}
+	defer rows.Close()
+
+	result, err := scanQuarantined(rows)
+	if err != nil {
+		return nil, fmt.Errorf("querying quarantine: %w", err)
+	}
+
+	return result, nil
+}
+
+func scanQuarantined(rows *sql.Rows) ([]models.QuarantinedRate, error) {
+	var result []models.QuarantinedRate
+	for rows.Next() {
+		var q models.QuarantinedRate
+		var reviewed sql.NullTime
+
+		err := rows.Scan(
+			&q.ID,
+			&q.Rate.Date,
+			&q.Rate.Base,
+			&q.Rate.Target,
+			&q.Rate.Value,
+			&q.Rate.Source,
+			&q.Rate.Fetched,
+			&q.Rate.Frequency,
+			&q.Reason,
+			&q.Status,
+			&q.Quarantined,
+			&reviewed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning quarantined rate: %w", err)
+		}
+
+		q.Reviewed = reviewed.Time
+		result = append(result, q)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ApproveQuarantined stores a pending rate as a regular observation
+func (db *DB) ApproveQuarantined(ctx context.Context, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reviewQuarantined(ctx, tx, id, models.QuarantineApproved); err != nil {
+		return err
+	}
+
+	query := `
+		insert into rates (date, base, target, rate, source, fetched_at, frequency)
+		select date, base, target, rate, source, fetched_at, frequency
+		from   quarantine
+		where  id = ?
+		on conflict (date, base, target, source) do update set
+			rate       = excluded.rate,
+			fetched_at = excluded.fetched_at,
+			frequency  = excluded.frequency
+	`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("storing approved rate: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RejectQuarantined keeps the row for the record but never stores it
+func (db *DB) RejectQuarantined(ctx context.Context, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := reviewQuarantined(ctx, tx, id, models.QuarantineRejected); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func reviewQuarantined(ctx context.Context, tx *sql.Tx, id int64, status string) error {
+	query := `
+		update quarantine
+		set    status = ?, reviewed_at = ?
+		where  id = ? and status = ?
+	`
+
+	result, err := tx.ExecContext(ctx, query, status, time.Now(), id, models.QuarantinePending)
+	if err != nil {
+		return fmt.Errorf("updating quarantine: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("updating quarantine: %w", err)
+	}
+
+	notPending := (affected == 0)
+	if notPending {
+		return fmt.Errorf("no pending quarantined rate with id %d", id)
+	}
+
+	return nil
+}
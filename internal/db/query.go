@@ -8,38 +8,14 @@ import (
 	"github.com/xhos/fxgo/internal/models"
 )
 
-func (db *DB) GetRatesBetween(ctx context.Context, startDate, endDate time.Time, base string, targets []string) ([]models.Rate, error) {
-	if len(targets) == 0 {
-		return []models.Rate{}, nil
-	}
-
-	queryTemplate := `
-		select date, base, target, rate, source, calculated, fetched_at
-		from   rates
-		where  base = ? and date >= ? and date <= ? and target in (%s)
-		order by date asc, target asc
-	`
-
-	placeholders, args := db.buildInClause([]any{base, startDate, endDate}, targets)
-	query := fmt.Sprintf(queryTemplate, placeholders)
-
-	rates, err := db.scanMultipleRates(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("querying rates between dates: %w", err)
-	}
-
-	return rates, nil
-}
-
 func (db *DB) GetAvailableCurrencies(ctx context.Context) ([]string, error) {
 	query := `
 		select distinct target
 		from   rates
-		where  calculated = 0
 		order by target
 	`
 
-	currencies, err := db.scanStringList(ctx, query)
+	currencies, err := scanStringList(ctx, db, query)
 	if err != nil {
 		return nil, fmt.Errorf("querying available currencies: %w", err)
 	}
@@ -51,11 +27,10 @@ func (db *DB) GetAvailableBases(ctx context.Context) ([]string, error) {
 	query := `
 		select distinct base
 		from   rates
-		where  calculated = 0
 		order by base
 	`
 
-	bases, err := db.scanStringList(ctx, query)
+	bases, err := scanStringList(ctx, db, query)
 	if err != nil {
 		return nil, fmt.Errorf("querying available bases: %w", err)
 	}
@@ -63,8 +38,8 @@ func (db *DB) GetAvailableBases(ctx context.Context) ([]string, error) {
 	return bases, nil
 }
 
-func (db *DB) scanStringList(ctx context.Context, query string, args ...any) ([]string, error) {
-	rows, err := db.QueryContext(ctx, query, args...)
+func scanStringList(ctx context.Context, conn queryer, query string, args ...any) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -100,3 +75,44 @@ func (db *DB) GetDateRange(ctx context.Context) (time.Time, time.Time, error) {
 
 	return minDate, maxDate, nil
 }
+
+// GetDirectRatesBetween returns stored observations in a date range, limited
+// to one source unless source is empty
+func (db *DB) GetDirectRatesBetween(ctx context.Context, startDate, endDate time.Time, source string) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rates
+		where  date >= ? and date <= ? and (? = '' or source = ?)
+		order by date asc, source asc, base asc, target asc
+	`
+
+	rates, err := scanMultipleRates(ctx, db, query, startDate, endDate, source, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying direct rates between dates: %w", err)
+	}
+
+	return rates, nil
+}
+
+// GetPairHistory returns up to limit observations of one source's pair
+// before a date, oldest first
+func (db *DB) GetPairHistory(ctx context.Context, base, target, source string, before time.Time, limit int) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from (
+			select date, base, target, rate, source, fetched_at, frequency
+			from   rates
+			where  base = ? and target = ? and source = ? and date < ?
+			order by date desc
+			limit  ?
+		)
+		order by date asc
+	`
+
+	rates, err := scanMultipleRates(ctx, db, query, base, target, source, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("querying pair history: %w", err)
+	}
+
+	return rates, nil
+}
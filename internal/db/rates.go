@@ -9,25 +9,29 @@ import (
 	"github.com/xhos/fxgo/internal/models"
 )
 
+// derived rates are never stored, so callers can hand over provider output as-is
+const upsertQuery = `
+	insert into rates (date, base, target, rate, source, fetched_at, frequency)
+	values (?, ?, ?, ?, ?, ?, ?)
+	on conflict (date, base, target, source) do update set
+		rate       = excluded.rate,
+		fetched_at = excluded.fetched_at,
+		frequency  = excluded.frequency
+`
+
 func (db *DB) InsertRate(ctx context.Context, rate models.Rate) error {
-	query := `
-		insert into rates (date, base, target, rate, source, calculated, fetched_at)
-		values (?, ?, ?, ?, ?, ?, ?)
-		on conflict (date, base, target) do update set
-			rate       = excluded.rate,
-			source     = excluded.source,
-			calculated = excluded.calculated,
-			fetched_at = excluded.fetched_at
-	`
+	if rate.Calculated {
+		return nil
+	}
 
-	_, err := db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, upsertQuery,
 		rate.Date,
 		rate.Base,
 		rate.Target,
 		rate.Value,
 		rate.Source,
-		rate.Calculated,
 		rate.Fetched,
+		frequencyOf(rate),
 	)
 
 	if err != nil {
@@ -48,15 +52,6 @@ func (db *DB) InsertRates(ctx context.Context, rates []models.Rate) error {
 	}
 	defer tx.Rollback()
 
-	upsertQuery := `
-		insert into rates (date, base, target, rate, source, calculated, fetched_at)
-		values (?, ?, ?, ?, ?, ?, ?)
-		on conflict (date, base, target) do update set
-			rate       = excluded.rate,
-			source     = excluded.source,
-			calculated = excluded.calculated,
-			fetched_at = excluded.fetched_at
-	`
 	stmt, err := tx.PrepareContext(ctx, upsertQuery)
 	if err != nil {
 		return fmt.Errorf("preparing statement: %w", err)
@@ -64,14 +59,18 @@ func (db *DB) InsertRates(ctx context.Context, rates []models.Rate) error {
 	defer stmt.Close()
 
 	for _, rate := range rates {
+		if rate.Calculated {
+			continue
+		}
+
 		_, err := stmt.ExecContext(ctx,
 			rate.Date,
 			rate.Base,
 			rate.Target,
 			rate.Value,
 			rate.Source,
-			rate.Calculated,
 			rate.Fetched,
+			frequencyOf(rate),
 		)
 		if err != nil {
 			return fmt.Errorf("inserting rate: %w", err)
@@ -85,88 +84,42 @@ func (db *DB) InsertRates(ctx context.Context, rates []models.Rate) error {
 	return nil
 }
 
-func (db *DB) GetRate(ctx context.Context, date time.Time, base, target string) (*models.Rate, error) {
+func (db *DB) latestEitherWay(ctx context.Context, base, target string) (*models.Rate, error) {
 	query := `
-		select date, base, target, rate, source, calculated, fetched_at
+		select date, base, target, rate, source, fetched_at, frequency
 		from   rates
-		where  date = ? and base = ? and target = ?
+		where  (base = ? and target = ?) or (base = ? and target = ?)
+		order by date desc, (base = ?) desc
+		limit  1
 	`
 
-	rate, err := db.scanSingleRate(ctx, query, date, base, target)
-	if err != nil {
-		return nil, fmt.Errorf("querying rate: %w", err)
-	}
-
-	return rate, nil
+	return scanSingleRate(ctx, db, query, base, target, target, base, base)
 }
 
-func (db *DB) GetLatestRate(ctx context.Context, base, target string) (*models.Rate, error) {
+func (db *DB) latestDates(ctx context.Context, limit int) ([]time.Time, error) {
 	query := `
-		select date, base, target, rate, source, calculated, fetched_at
+		select distinct date
 		from   rates
-		where  base = ? and target = ?
 		order by date desc
-		limit  1
-	`
-
-	rate, err := db.scanSingleRate(ctx, query, base, target)
-	if err != nil {
-		return nil, fmt.Errorf("querying latest rate: %w", err)
-	}
-
-	return rate, nil
-}
-
-func (db *DB) GetRatesForDate(ctx context.Context, date time.Time, base string, targets []string) ([]models.Rate, error) {
-	if len(targets) == 0 {
-		return []models.Rate{}, nil
-	}
-
-	queryTemplate := `
-		select date, base, target, rate, source, calculated, fetched_at
-		from   rates
-		where  date = ? and base = ? and target in (%s)
+		limit  ?
 	`
 
-	placeholders, args := db.buildInClause([]any{date, base}, targets)
-	query := fmt.Sprintf(queryTemplate, placeholders)
-
-	rates, err := db.scanMultipleRates(ctx, query, args...)
+	rows, err := db.QueryContext(ctx, query, limit)
 	if err != nil {
-		return nil, fmt.Errorf("querying rates: %w", err)
-	}
-
-	return rates, nil
-}
-
-func (db *DB) GetLatestRates(ctx context.Context, base string, targets []string) ([]models.Rate, error) {
-	if len(targets) == 0 {
-		return []models.Rate{}, nil
+		return nil, err
 	}
+	defer rows.Close()
 
-	// join with subquery to get most recent date for each target
-	queryTemplate := `
-		select r1.date, r1.base, r1.target, r1.rate, r1.source, r1.calculated, r1.fetched_at
-		from   rates r1
-		inner join (
-			select target, max(date) as max_date
-			from   rates
-			where  base = ? and target in (%s)
-			group by target
-		) r2 on r1.target = r2.target and r1.date = r2.max_date
-		where  r1.base = ?
-	`
-
-	placeholders, args := db.buildInClause([]any{base}, targets)
-	args = append(args, base)
-	query := fmt.Sprintf(queryTemplate, placeholders)
-
-	rates, err := db.scanMultipleRates(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("querying latest rates: %w", err)
+	var dates []time.Time
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
 	}
 
-	return rates, nil
+	return dates, rows.Err()
 }
 
 func (db *DB) GetNearestDate(ctx context.Context, date time.Time) (time.Time, error) {
@@ -192,18 +145,23 @@ func (db *DB) GetNearestDate(ctx context.Context, date time.Time) (time.Time, er
 	return nearestDate, nil
 }
 
-func (db *DB) scanSingleRate(ctx context.Context, query string, args ...any) (*models.Rate, error) {
+// queryer is *sql.DB or *sql.Tx, so the SQL stores share their scanning
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanSingleRate(ctx context.Context, conn queryer, query string, args ...any) (*models.Rate, error) {
 	var rate models.Rate
-	var calculatedInt int
 
-	err := db.QueryRowContext(ctx, query, args...).Scan(
+	err := conn.QueryRowContext(ctx, query, args...).Scan(
 		&rate.Date,
 		&rate.Base,
 		&rate.Target,
 		&rate.Value,
 		&rate.Source,
-		&calculatedInt,
 		&rate.Fetched,
+		&rate.Frequency,
 	)
 
 	notFound := (err == sql.ErrNoRows)
@@ -214,13 +172,11 @@ func (db *DB) scanSingleRate(ctx context.Context, query string, args ...any) (*m
 		return nil, err
 	}
 
-	rate.Calculated = (calculatedInt != 0)
-
 	return &rate, nil
 }
 
-func (db *DB) scanMultipleRates(ctx context.Context, query string, args ...any) ([]models.Rate, error) {
-	rows, err := db.QueryContext(ctx, query, args...)
+func scanMultipleRates(ctx context.Context, conn queryer, query string, args ...any) ([]models.Rate, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -229,7 +185,6 @@ func (db *DB) scanMultipleRates(ctx context.Context, query string, args ...any)
 	var rates []models.Rate
 	for rows.Next() {
 		var rate models.Rate
-		var calculatedInt int
 
 		err := rows.Scan(
 			&rate.Date,
@@ -237,14 +192,13 @@ func (db *DB) scanMultipleRates(ctx context.Context, query string, args ...any)
 			&rate.Target,
 			&rate.Value,
 			&rate.Source,
-			&calculatedInt,
 			&rate.Fetched,
+			&rate.Frequency,
 		)
 		if err != nil {
 			return nil, err
 		}
 
-		rate.Calculated = (calculatedInt != 0)
 		rates = append(rates, rate)
 	}
 
@@ -0,0 +1,255 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// forEachStore runs a test against every store, PostgreSQL only when
+// FXGO_TEST_POSTGRES_DSN points at a server
+func forEachStore(t *testing.T, test func(t *testing.T, database Store)) {
+	t.Run("sqlite", func(t *testing.T) {
+		database, err := Open(filepath.Join(t.TempDir(), "fxgo.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { database.Close() })
+
+		test(t, database)
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemory())
+	})
+
+	t.Run("postgres", func(t *testing.T) {
+		test(t, openTestPostgres(t))
+	})
+}
+
+// openTestPostgres gives each test a schema of its own, dropped afterwards
+func openTestPostgres(t *testing.T) *Postgres {
+	t.Helper()
+
+	dsn := os.Getenv("FXGO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("FXGO_TEST_POSTGRES_DSN is not set")
+	}
+
+	cfg, err := pq.NewConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := openPQ(t, cfg)
+
+	schema := fmt.Sprintf("fxgo_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("create schema " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("drop schema " + schema + " cascade") })
+
+	cfg.Runtime = maps.Clone(cfg.Runtime)
+	if cfg.Runtime == nil {
+		cfg.Runtime = make(map[string]string)
+	}
+	cfg.Runtime["search_path"] = schema
+
+	database, err := OpenPostgres(openPQ(t, cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return database
+}
+
+func openPQ(t *testing.T, cfg pq.Config) *sql.DB {
+	t.Helper()
+
+	connector, err := pq.NewConnectorConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sqlDB := sql.OpenDB(connector)
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+func TestDerivedReads(t *testing.T) {
+	forEachStore(t, testDerivedReads)
+}
+
+func testDerivedReads(t *testing.T, database Store) {
+	ctx := context.Background()
+
+	day1 := time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	err := database.InsertRates(ctx, []models.Rate{
+		{Date: day1, Base: "EUR", Target: "USD", Value: 1.17, Source: "ECB", Fetched: now},
+		{Date: day1, Base: "EUR", Target: "JPY", Value: 176, Source: "ECB", Fetched: now},
+		{Date: day2, Base: "EUR", Target: "USD", Value: 1.16, Source: "ECB", Fetched: now},
+		{Date: day2, Base: "CAD", Target: "EUR", Value: 0.61, Source: "BankOfCanada", Fetched: now},
+		{Date: day2, Base: "USD", Target: "JPY", Value: 999, Source: "ECB", Fetched: now, Calculated: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stored pair", func(t *testing.T) {
+		rate, err := database.GetRate(ctx, day1, "EUR", "USD")
+		if err != nil || rate == nil {
+			t.Fatalf("got %v, %v", rate, err)
+		}
+
+		if rate.Value != 1.17 || rate.Calculated {
+			t.Errorf("unexpected rate: %+v", rate)
+		}
+	})
+
+	t.Run("cross via pivot", func(t *testing.T) {
+		rate, err := database.GetRate(ctx, day1, "USD", "JPY")
+		if err != nil || rate == nil {
+			t.Fatalf("got %v, %v", rate, err)
+		}
+
+		if math.Abs(rate.Value-176/1.17) > 1e-9 || !rate.Calculated {
+			t.Errorf("unexpected rate: %+v", rate)
+		}
+	})
+
+	t.Run("calculated input is not stored", func(t *testing.T) {
+		rate, err := database.GetRate(ctx, day2, "USD", "JPY")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if rate != nil {
+			t.Errorf("expected no rate, got %+v", rate)
+		}
+	})
+
+	t.Run("between dates", func(t *testing.T) {
+		rates, err := database.GetRatesBetween(ctx, day1, day2, "USD", []string{"EUR", "CAD"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// EUR on both days, CAD only once BoC published
+		if len(rates) != 3 {
+			t.Fatalf("got %d rates, want 3: %+v", len(rates), rates)
+		}
+	})
+
+	t.Run("latest falls back per target", func(t *testing.T) {
+		rates, err := database.GetLatestRates(ctx, "EUR", []string{"USD", "JPY", "CAD"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := map[string]time.Time{"USD": day2, "JPY": day1, "CAD": day2}
+		if len(rates) != len(want) {
+			t.Fatalf("got %d rates, want %d", len(rates), len(want))
+		}
+
+		for _, r := range rates {
+			if !r.Date.Equal(want[r.Target]) {
+				t.Errorf("%s dated %s, want %s", r.Target, r.Date, want[r.Target])
+			}
+		}
+	})
+}
+
+func TestInverseLookups(t *testing.T) {
+	forEachStore(t, testInverseLookups)
+}
+
+func testInverseLookups(t *testing.T, database Store) {
+	ctx := context.Background()
+
+	day1 := time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	err := database.InsertRates(ctx, []models.Rate{
+		{Date: day1, Base: "USD", Target: "EUR", Value: 0.85, Source: "IMF", Fetched: time.Now()},
+		{Date: day2, Base: "EUR", Target: "USD", Value: 1.16, Source: "ECB", Fetched: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rate, err := database.GetRate(ctx, day2, "USD", "EUR")
+	if err != nil || rate == nil {
+		t.Fatalf("got %v, %v", rate, err)
+	}
+
+	hasProvenance := len(rate.Derivation) == 1 && rate.Derivation[0].Inverted && rate.Derivation[0].Source == "ECB"
+	if math.Abs(rate.Value-1/1.16) > 1e-12 || !rate.Calculated || !hasProvenance {
+		t.Errorf("unexpected inverse rate: %+v", rate)
+	}
+
+	latest, err := database.GetLatestRate(ctx, "USD", "EUR")
+	if err != nil || latest == nil {
+		t.Fatalf("got %v, %v", latest, err)
+	}
+
+	// the inverse on day2 is more recent than the stored direction on day1
+	if !latest.Date.Equal(day2) || !latest.Calculated || latest.Base != "USD" || len(latest.Derivation) != 1 {
+		t.Errorf("unexpected latest rate: %+v", latest)
+	}
+
+	direct, err := database.GetLatestRate(ctx, "EUR", "USD")
+	if err != nil || direct == nil {
+		t.Fatalf("got %v, %v", direct, err)
+	}
+
+	if direct.Calculated || direct.Derivation != nil {
+		t.Errorf("unexpected direct rate: %+v", direct)
+	}
+}
+
+func TestDefaultFrequency(t *testing.T) {
+	forEachStore(t, testDefaultFrequency)
+}
+
+func testDefaultFrequency(t *testing.T, database Store) {
+	ctx := context.Background()
+	day := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	err := database.InsertRates(ctx, []models.Rate{
+		{Date: day, Base: "EUR", Target: "USD", Value: 1.16, Source: "ECB", Fetched: time.Now()},
+		{Date: day, Base: "USD", Target: "INR", Value: 88.1, Source: "BIS", Fetched: time.Now(), Frequency: models.FrequencyMonthly},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rate that doesn't say is stored as daily, whatever the backend
+	tests := []struct {
+		base, target, frequency string
+	}{
+		{"EUR", "USD", models.FrequencyDaily},
+		{"USD", "INR", models.FrequencyMonthly},
+	}
+
+	for _, tt := range tests {
+		rate, err := database.GetRate(ctx, day, tt.base, tt.target)
+		if err != nil || rate == nil {
+			t.Fatalf("got %v, %v", rate, err)
+		}
+		if rate.Frequency != tt.frequency {
+			t.Errorf("%s/%s: got frequency %q, want %q", tt.base, tt.target, rate.Frequency, tt.frequency)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// revisions are filled by triggers on rates, see the migrations
+
+// latest revision of each pair and source known at a time; fetched_at is
+// compared through julianday since stored timestamps vary in precision and zone
+const knownAtQuery = `
+	select date, base, target, rate, source, fetched_at, frequency
+	from (
+		select date, base, target, rate, source, fetched_at, frequency,
+		       row_number() over (
+		           partition by date, base, target, source
+		           order by julianday(fetched_at) desc, id desc
+		       ) as revision
+		from   rate_revisions
+		where  date >= ? and date <= ? and julianday(fetched_at) <= julianday(?)
+	)
+	where  revision = 1
+	order by date asc, source asc, base asc, target asc
+`
+
+// GetRevisions lists every value stored for a pair on a date, oldest first,
+// limited to one source unless source is empty
+func (db *DB) GetRevisions(ctx context.Context, date time.Time, base, target, source string) ([]models.Rate, error) {
+	query := `
+		select date, base, target, rate, source, fetched_at, frequency
+		from   rate_revisions
+		where  date = ? and base = ? and target = ? and (? = '' or source = ?)
+		order by source asc, julianday(fetched_at) asc, id asc
+	`
+
+	revisions, err := scanMultipleRates(ctx, db, query, date, base, target, source, source)
+	if err != nil {
+		return nil, fmt.Errorf("querying revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (db *DB) directRatesKnownAt(ctx context.Context, startDate, endDate, knownAt time.Time) ([]models.Rate, error) {
+	return scanMultipleRates(ctx, db, knownAtQuery, startDate, endDate, knownAt)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestRevisions(t *testing.T) {
+	forEachStore(t, testRevisions)
+}
+
+func testRevisions(t *testing.T, database Store) {
+	ctx := context.Background()
+
+	day := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	firstFetch := time.Date(2025, 9, 1, 16, 0, 0, 0, time.UTC)
+	refetch := firstFetch.Add(time.Hour)
+	revisedAt := firstFetch.Add(48 * time.Hour)
+
+	rate := func(value float64, fetched time.Time) models.Rate {
+		return models.Rate{Date: day, Base: "EUR", Target: "USD", Value: value, Source: "ECB", Fetched: fetched, Frequency: models.FrequencyDaily}
+	}
+
+	for _, r := range []models.Rate{rate(1.17, firstFetch), rate(1.17, refetch), rate(1.18, revisedAt)} {
+		if err := database.InsertRate(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("distinct values are kept", func(t *testing.T) {
+		revisions, err := database.GetRevisions(ctx, day, "EUR", "USD", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correct := (len(revisions) == 2 && revisions[0].Value == 1.17 && revisions[1].Value == 1.18)
+		if !correct {
+			t.Fatalf("unexpected revisions: %+v", revisions)
+		}
+		if !revisions[0].Fetched.Equal(firstFetch) {
+			t.Errorf("first revision fetched %s, want %s", revisions[0].Fetched, firstFetch)
+		}
+	})
+
+	t.Run("current value is the latest revision", func(t *testing.T) {
+		current, err := database.GetRate(ctx, day, "EUR", "USD")
+		if err != nil || current == nil || current.Value != 1.18 {
+			t.Errorf("got %+v, %v", current, err)
+		}
+	})
+
+	t.Run("as known at", func(t *testing.T) {
+		cases := map[string]struct {
+			knownAt time.Time
+			want    float64
+		}{
+			"before the first fetch": {firstFetch.Add(-time.Minute), 0},
+			"after the first fetch":  {firstFetch.Add(time.Minute), 1.17},
+			"just before revision":   {revisedAt.Add(-time.Second), 1.17},
+			"after revision":         {revisedAt, 1.18},
+		}
+
+		for name, tc := range cases {
+			rate, err := database.GetRateKnownAt(ctx, day, "USD", "EUR", tc.knownAt)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+
+			got := 0.0
+			if rate != nil {
+				got = 1 / rate.Value
+			}
+			if got != tc.want {
+				t.Errorf("%s: got %v, want %v", name, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("as-of lookup honours known at", func(t *testing.T) {
+		result, err := database.GetRateAsOf(ctx, "EUR", "USD", day.AddDate(0, 0, 1), AsOfOptions{KnownAt: refetch})
+		if err != nil || result == nil {
+			t.Fatalf("got %+v, %v", result, err)
+		}
+		if result.Rate.Value != 1.17 {
+			t.Errorf("got %v, want 1.17", result.Rate.Value)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/triangulate"
+)
+
+// Store is what the rest of fxgo depends on. SQLite (Open), in-memory
+// (NewMemory) and PostgreSQL (OpenPostgres) implement it; derived reads are
+// shared between them, see rateReader.
+type Store interface {
+	InsertRate(ctx context.Context, rate models.Rate) error
+	InsertRates(ctx context.Context, rates []models.Rate) error
+
+	GetRate(ctx context.Context, date time.Time, base, target string) (*models.Rate, error)
+	GetRatesForDate(ctx context.Context, date time.Time, base string, targets []string) ([]models.Rate, error)
+	GetRatesBetween(ctx context.Context, startDate, endDate time.Time, base string, targets []string) ([]models.Rate, error)
+	GetLatestRate(ctx context.Context, base, target string) (*models.Rate, error)
+	GetLatestRates(ctx context.Context, base string, targets []string) ([]models.Rate, error)
+	GetRateAsOf(ctx context.Context, base, target string, date time.Time, opts AsOfOptions) (*AsOfResult, error)
+	Triangulate(ctx context.Context, date time.Time, base, target string, priority []string) (triangulate.Result, error)
+	SetSourcePriority(sources []string)
+
+	GetDirectRatesForDate(ctx context.Context, date time.Time) ([]models.Rate, error)
+	GetDirectRatesBetween(ctx context.Context, startDate, endDate time.Time, source string) ([]models.Rate, error)
+	GetPairHistory(ctx context.Context, base, target, source string, before time.Time, limit int) ([]models.Rate, error)
+
+	GetNearestDate(ctx context.Context, date time.Time) (time.Time, error)
+	GetDateRange(ctx context.Context) (time.Time, time.Time, error)
+	GetAvailableCurrencies(ctx context.Context) ([]string, error)
+	GetAvailableBases(ctx context.Context) ([]string, error)
+
+	ReplaceCurrencies(ctx context.Context, source string, infos []models.CurrencyInfo) error
+	GetCurrencies(ctx context.Context, source string) ([]models.CurrencyInfo, error)
+
+	GetRevisions(ctx context.Context, date time.Time, base, target, source string) ([]models.Rate, error)
+	GetDirectRatesKnownAt(ctx context.Context, date, knownAt time.Time) ([]models.Rate, error)
+	GetRateKnownAt(ctx context.Context, date time.Time, base, target string, knownAt time.Time) (*models.Rate, error)
+	GetRatesBetweenKnownAt(ctx context.Context, startDate, endDate time.Time, base string, targets []string, knownAt time.Time) ([]models.Rate, error)
+
+	QuarantineRate(ctx context.Context, rate models.Rate, reason string) error
+	GetQuarantined(ctx context.Context, status string) ([]models.QuarantinedRate, error)
+	ApproveQuarantined(ctx context.Context, id int64) error
+	RejectQuarantined(ctx context.Context, id int64) error
+
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*Memory)(nil)
+	_ Store = (*Postgres)(nil)
+)
+
+// backend is the scanning each store implements, rateReader derives every
+// other read from it. Direct rates come ordered by date, source, base, target.
+type backend interface {
+	GetDirectRatesBetween(ctx context.Context, startDate, endDate time.Time, source string) ([]models.Rate, error)
+	// directRatesKnownAt returns the latest revision of each stored rate fetched at or before knownAt
+	directRatesKnownAt(ctx context.Context, startDate, endDate, knownAt time.Time) ([]models.Rate, error)
+	// latestDates lists the most recent distinct dates with any rate, newest first
+	latestDates(ctx context.Context, limit int) ([]time.Time, error)
+	// latestEitherWay returns the most recent stored base/target or target/base
+	// rate, preferring base/target on the same date
+	latestEitherWay(ctx context.Context, base, target string) (*models.Rate, error)
+}
+
+// frequencyOf is the frequency every store writes, a rate that doesn't say
+// is a daily one
+func frequencyOf(rate models.Rate) string {
+	if rate.Frequency == "" {
+		return models.FrequencyDaily
+	}
+	return rate.Frequency
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestStoreMetadata(t *testing.T) {
+	forEachStore(t, testStoreMetadata)
+}
+
+func testStoreMetadata(t *testing.T, database Store) {
+	ctx := context.Background()
+
+	day1 := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	day2 := time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC)
+	day3 := time.Date(2025, 4, 4, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	err := database.InsertRates(ctx, []models.Rate{
+		{Date: day1, Base: "EUR", Target: "USD", Value: 1.08, Source: "ECB", Fetched: now, Frequency: models.FrequencyDaily},
+		{Date: day2, Base: "EUR", Target: "USD", Value: 1.09, Source: "ECB", Fetched: now, Frequency: models.FrequencyDaily},
+		{Date: day3, Base: "EUR", Target: "USD", Value: 1.10, Source: "ECB", Fetched: now, Frequency: models.FrequencyDaily},
+		{Date: day2, Base: "CAD", Target: "USD", Value: 0.70, Source: "BankOfCanada", Fetched: now, Frequency: models.FrequencyDaily},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("date range and nearest date", func(t *testing.T) {
+		minDate, maxDate, err := database.GetDateRange(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !minDate.Equal(day1) || !maxDate.Equal(day3) {
+			t.Errorf("got %s..%s", minDate, maxDate)
+		}
+
+		nearest, err := database.GetNearestDate(ctx, day3.AddDate(0, 0, -1))
+		if err != nil || !nearest.Equal(day2) {
+			t.Errorf("got %s, %v", nearest, err)
+		}
+
+		if _, err := database.GetNearestDate(ctx, day1.AddDate(0, 0, -1)); err == nil {
+			t.Error("expected an error before the first date")
+		}
+	})
+
+	t.Run("available currencies", func(t *testing.T) {
+		targets, err := database.GetAvailableCurrencies(ctx)
+		if err != nil || !slices.Equal(targets, []string{"USD"}) {
+			t.Errorf("targets %v, %v", targets, err)
+		}
+
+		bases, err := database.GetAvailableBases(ctx)
+		if err != nil || !slices.Equal(bases, []string{"CAD", "EUR"}) {
+			t.Errorf("bases %v, %v", bases, err)
+		}
+	})
+
+	t.Run("pair history", func(t *testing.T) {
+		history, err := database.GetPairHistory(ctx, "EUR", "USD", "ECB", day3, 1)
+		correct := (err == nil && len(history) == 1 && history[0].Value == 1.09)
+		if !correct {
+			t.Errorf("got %+v, %v", history, err)
+		}
+	})
+
+	t.Run("direct rates by source", func(t *testing.T) {
+		rates, err := database.GetDirectRatesBetween(ctx, day1, day3, "BankOfCanada")
+		correct := (err == nil && len(rates) == 1 && rates[0].Base == "CAD")
+		if !correct {
+			t.Errorf("got %+v, %v", rates, err)
+		}
+	})
+
+	t.Run("currency catalogue", func(t *testing.T) {
+		infos := []models.CurrencyInfo{
+			{Currency: "USD", FirstDate: day1, Active: true, Refreshed: now},
+			{Currency: "CYP", LastDate: day1, Active: false, Refreshed: now},
+		}
+		if err := database.ReplaceCurrencies(ctx, "ECB", infos); err != nil {
+			t.Fatal(err)
+		}
+
+		stored, err := database.GetCurrencies(ctx, "ECB")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correct := (len(stored) == 2 && stored[0].Currency == "CYP" && !stored[0].Active && stored[1].Active && stored[1].Source == "ECB")
+		if !correct {
+			t.Errorf("unexpected catalogue: %+v", stored)
+		}
+	})
+
+	t.Run("quarantine review", func(t *testing.T) {
+		suspicious := models.Rate{Date: day3, Base: "CAD", Target: "USD", Value: 7, Source: "BankOfCanada", Fetched: now, Frequency: models.FrequencyDaily}
+		if err := database.QuarantineRate(ctx, suspicious, "decimal shift"); err != nil {
+			t.Fatal(err)
+		}
+
+		pending, err := database.GetQuarantined(ctx, models.QuarantinePending)
+		if err != nil || len(pending) != 1 {
+			t.Fatalf("got %+v, %v", pending, err)
+		}
+
+		if err := database.ApproveQuarantined(ctx, pending[0].ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.RejectQuarantined(ctx, pending[0].ID); err == nil {
+			t.Error("a reviewed rate can't be reviewed again")
+		}
+
+		rate, err := database.GetRate(ctx, day3, "CAD", "USD")
+		if err != nil || rate == nil || rate.Value != 7 {
+			t.Errorf("approved rate not stored: %+v, %v", rate, err)
+		}
+	})
+}
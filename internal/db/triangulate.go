@@ -0,0 +1,25 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/xhos/fxgo/internal/triangulate"
+)
+
+// Triangulate derives base/target for a date from the direct rates of every
+// source, see triangulate.Graph for how the path is chosen
+func (r *rateReader) Triangulate(ctx context.Context, date time.Time, base, target string, priority []string) (triangulate.Result, error) {
+	rates, err := r.GetDirectRatesForDate(ctx, date)
+	if err != nil {
+		return triangulate.Result{}, err
+	}
+
+	result, err := triangulate.NewGraph(rates, priority).Convert(base, target)
+	if err != nil {
+		return triangulate.Result{}, fmt.Errorf("triangulating %s/%s on %s: %w", base, target, date.Format("2006-01-02"), err)
+	}
+
+	return result, nil
+}
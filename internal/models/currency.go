@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CurrencyInfo describes one currency in a source's live series catalogue
+type CurrencyInfo struct {
+	Source    string
+	Currency  string
+	FirstDate time.Time
+	LastDate  time.Time
+	// Active is false once the series has stopped updating
+	Active    bool
+	Refreshed time.Time
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Hop is one leg of a derived conversion, taken from a stored direct rate
+type Hop struct {
+	From   string
+	To     string
+	Value  float64
+	Source string
+	Date   time.Time
+	// Inverted is set when the stored rate was quoted To/From and got reciprocated
+	Inverted bool
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// review states of a quarantined rate
+const (
+	QuarantinePending  = "pending"
+	QuarantineApproved = "approved"
+	QuarantineRejected = "rejected"
+)
+
+// QuarantinedRate is an incoming rate held back as suspicious until reviewed
+type QuarantinedRate struct {
+	ID          int64
+	Rate        Rate
+	Reason      string
+	Status      string
+	Quarantined time.Time
+	// Reviewed is zero while the rate is pending
+	Reviewed time.Time
+}
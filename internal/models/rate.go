@@ -2,6 +2,12 @@ package models
 
 import "time"
 
+// observation frequencies, following SDMX FREQ codes
+const (
+	FrequencyDaily   = "D"
+	FrequencyMonthly = "M"
+)
+
 type Rate struct {
 	Base       string
 	Target     string
@@ -10,10 +16,17 @@ type Rate struct {
 	Source     string
 	Fetched    time.Time
 	Calculated bool
+	Frequency  string
+	// Derivation lists the stored rates a calculated rate was derived from
+	Derivation []Hop
 }
 
 type RateRequest struct {
 	Base    string
 	Targets []string
 	Date    time.Time
+	// IfModified is for callers that already hold the answer to the same
+	// request, e.g. a daily poller: a source that answers 304 then ends in
+	// common.ErrNotModified instead of being parsed again
+	IfModified bool
 }
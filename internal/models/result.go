@@ -0,0 +1,59 @@
+package models
+
+import "fmt"
+
+// what became of one requested currency
+const (
+	StatusReturned = "returned"
+	// the source carries the currency but published no value for the day
+	StatusNotPublished = "not_published"
+	// the source doesn't carry the currency at all
+	StatusUnsupported = "unsupported"
+	// the source sent a value that couldn't be read
+	StatusParseError = "parse_error"
+	// the request to the source failed as a whole
+	StatusFailed = "failed"
+)
+
+// TargetResult is the outcome for one requested target
+type TargetResult struct {
+	Currency string
+	Status   string
+	// Detail carries the parse error or failure, empty otherwise
+	Detail string
+}
+
+func (t TargetResult) String() string {
+	if t.Detail == "" {
+		return fmt.Sprintf("%s: %s", t.Currency, t.Status)
+	}
+	return fmt.Sprintf("%s: %s (%s)", t.Currency, t.Status, t.Detail)
+}
+
+// RateResult answers a RateRequest: the rates found and, in request order,
+// what became of every requested target
+type RateResult struct {
+	Rates   []Rate
+	Targets []TargetResult
+}
+
+// Missing lists the targets that weren't returned
+func (r RateResult) Missing() []TargetResult {
+	var missing []TargetResult
+	for _, target := range r.Targets {
+		if target.Status != StatusReturned {
+			missing = append(missing, target)
+		}
+	}
+	return missing
+}
+
+// Status is the outcome of a target, empty for currencies that weren't requested
+func (r RateResult) Status(currency string) string {
+	for _, target := range r.Targets {
+		if target.Currency == currency {
+			return target.Status
+		}
+	}
+	return ""
+}
@@ -4,6 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,26 +16,64 @@ import (
 )
 
 type Provider struct {
-	baseURL string
-	client  *common.HTTPClient
+	baseURL    string
+	client     *common.HTTPClient
+	currencies *common.CurrencySet
 }
 
+// observations map "d" to the date and each series name to an observedValue,
+// cells are decoded one by one so a malformed one only costs its own rate
 type response struct {
-	Observations []map[string]any `json:"observations"`
+	SeriesDetail map[string]json.RawMessage   `json:"seriesDetail"`
+	Observations []map[string]json.RawMessage `json:"observations"`
 }
 
-func New() *Provider {
-	return &Provider{
-		baseURL: "https://www.bankofcanada.ca/valet",
-		client:  common.NewHTTPClient(30 * time.Second),
+type observedValue struct {
+	V string `json:"v"`
+}
+
+// group holding every daily FX series against CAD
+const fxGroup = "FX_RATES_DAILY"
+
+type Option func(*Provider)
+
+// WithBaseURL points the provider at another Valet API, e.g. a test server
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces the default client, e.g. to add a cache or replay fixtures
+func WithHTTPClient(client *common.HTTPClient) Option {
+	return func(p *Provider) {
+		p.client = client
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		baseURL:    "https://www.bankofcanada.ca/valet",
+		client:     common.NewHTTPClient(30 * time.Second),
+		currencies: common.NewCurrencySet(defaultCurrencies),
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
 func (p *Provider) Name() string {
 	return "BankOfCanada"
 }
 
-func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
+func (p *Provider) Base() string {
+	return "CAD"
+}
+
+func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
 	isDirectCAD := (req.Base == "CAD")
 	if isDirectCAD {
 		return p.fetchDirectRates(ctx, req)
@@ -40,56 +82,62 @@ func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) ([]mo
 	return p.fetchCrossRates(ctx, req)
 }
 
-func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
+func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
 	seriesNames := p.buildSeriesNames(req.Targets)
 	url := p.buildURL(seriesNames, req.Date)
 
-	body, err := p.client.Get(ctx, url)
+	body, err := p.client.FetchBody(ctx, url, nil, req.IfModified)
 	if err != nil {
-		return nil, fmt.Errorf("fetching from bank of canada: %w", err)
+		return models.RateResult{}, fmt.Errorf("fetching from bank of canada: %w", err)
 	}
 
 	var data response
 	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return models.RateResult{}, fmt.Errorf("parsing response: %w", err)
 	}
 
-	rates := p.parseResponse(data, "CAD", req.Date)
+	rates, report := p.parseResponse(data, "CAD", req.Date)
+	report.Warn(p.Name())
+
 	if err := common.ValidateRates(rates); err != nil {
-		return nil, fmt.Errorf("validating rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("validating rates: %w", err)
 	}
 
-	return rates, nil
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
 }
 
-func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
+func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
 
 	// fetch both base and targets to calculate cross-rates via CAD
 	allCurrencies := append([]string{req.Base}, req.Targets...)
+	// CAD is the pivot and has no series of its own
+	allCurrencies = slices.DeleteFunc(allCurrencies, func(c string) bool { return c == "CAD" })
 	seriesNames := p.buildSeriesNames(allCurrencies)
 	url := p.buildURL(seriesNames, req.Date)
 
-	body, err := p.client.Get(ctx, url)
+	body, err := p.client.FetchBody(ctx, url, nil, req.IfModified)
 	if err != nil {
-		return nil, fmt.Errorf("fetching from bank of canada: %w", err)
+		return models.RateResult{}, fmt.Errorf("fetching from bank of canada: %w", err)
 	}
 
 	var data response
 	if err := json.Unmarshal(body, &data); err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return models.RateResult{}, fmt.Errorf("parsing response: %w", err)
 	}
 
-	cadRates := p.parseResponse(data, "CAD", req.Date)
+	cadRates, report := p.parseResponse(data, "CAD", req.Date)
+	report.Warn(p.Name())
+
 	if err := common.ValidateRates(cadRates); err != nil {
-		return nil, fmt.Errorf("validating cad rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("validating cad rates: %w", err)
 	}
 
 	rates, err := common.CalculateCrossRates(cadRates, req.Base, req.Targets)
 	if err != nil {
-		return nil, fmt.Errorf("calculating cross rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("calculating cross rates: %w", err)
 	}
 
-	return rates, nil
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
 }
 
 // buildSeriesNames converts currency codes to BoC series names (e.g., "USD" -> "FXUSDCAD")
@@ -99,6 +147,9 @@ func (p *Provider) buildSeriesNames(currencies []string) []string {
 		seriesName := fmt.Sprintf("FX%sCAD", currency)
 		series = append(series, seriesName)
 	}
+
+	// sorted so the same set of currencies is always the same request
+	slices.Sort(series)
 	return series
 }
 
@@ -119,23 +170,35 @@ func (p *Provider) buildURL(seriesNames []string, date time.Time) string {
 
 // parseResponse extracts rates from BoC API response
 // BoC returns foreign-to-CAD rates, so we invert them for CAD-based queries
-func (p *Provider) parseResponse(data response, base string, requestDate time.Time) []models.Rate {
+func (p *Provider) parseResponse(data response, base string, requestDate time.Time) ([]models.Rate, common.ParseReport) {
 	var rates []models.Rate
+	var report common.ParseReport
 	now := time.Now()
 
-	for _, obs := range data.Observations {
-		date, skip := p.parseDate(obs, requestDate)
+	for i, obs := range data.Observations {
+		record := fmt.Sprintf("observation %d", i+1)
+
+		date, skip, err := p.parseDate(obs, requestDate)
+		if err != nil {
+			report.Skip(record, "", err.Error())
+			continue
+		}
 		if skip {
 			continue
 		}
 
-		for key, val := range obs {
+		// sorted so the report lists series in a stable order
+		for _, key := range slices.Sorted(maps.Keys(obs)) {
 			isDateField := (key == "d")
 			if isDateField {
 				continue
 			}
 
-			rate, skip := p.parseRate(key, val, base, date, now)
+			rate, skip, err := p.parseRate(key, obs[key], base, date, now)
+			if err != nil {
+				report.Skip(record, p.extractCurrency(key), err.Error())
+				continue
+			}
 			if skip {
 				continue
 			}
@@ -144,56 +207,65 @@ func (p *Provider) parseResponse(data response, base string, requestDate time.Ti
 		}
 	}
 
-	return rates
+	report.Parsed = len(rates)
+	return rates, report
 }
 
-func (p *Provider) parseDate(obs map[string]any, requestDate time.Time) (time.Time, bool) {
-	dateStr, ok := obs["d"].(string)
+// parseDate skips observations of other days, a missing or malformed date is an error
+func (p *Provider) parseDate(obs map[string]json.RawMessage, requestDate time.Time) (time.Time, bool, error) {
+	raw, ok := obs["d"]
 	if !ok {
-		return time.Time{}, true
+		return time.Time{}, true, fmt.Errorf("no date")
+	}
+
+	var dateStr string
+	if err := json.Unmarshal(raw, &dateStr); err != nil {
+		return time.Time{}, true, fmt.Errorf("invalid date %s", raw)
 	}
 
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		return time.Time{}, true
+		return time.Time{}, true, fmt.Errorf("invalid date %q", dateStr)
 	}
 
 	hasSpecificDate := !requestDate.IsZero()
 	wrongDate := hasSpecificDate && !isSameDay(date, requestDate)
 	if wrongDate {
-		return time.Time{}, true
+		return time.Time{}, true, nil
 	}
 
-	return date, false
+	return date, false, nil
 }
 
-func (p *Provider) parseRate(key string, val any, base string, date time.Time, fetchedAt time.Time) (models.Rate, bool) {
+// parseRate skips series without a value that day, anything that isn't a
+// series cell holding a positive number is an error
+func (p *Provider) parseRate(key string, raw json.RawMessage, base string, date time.Time, fetchedAt time.Time) (models.Rate, bool, error) {
 	currency := p.extractCurrency(key)
 	if currency == "" {
-		return models.Rate{}, true
+		return models.Rate{}, true, fmt.Errorf("unexpected field %q", key)
 	}
 
-	valueMap, ok := val.(map[string]any)
-	if !ok {
-		return models.Rate{}, true
+	var cell observedValue
+	if err := json.Unmarshal(raw, &cell); err != nil {
+		return models.Rate{}, true, fmt.Errorf("malformed cell %s", truncate(raw))
 	}
 
-	valueStr, ok := valueMap["v"].(string)
-	if !ok {
-		return models.Rate{}, true
+	notPublished := (cell.V == "")
+	if notPublished {
+		return models.Rate{}, true, nil
 	}
 
-	var value float64
-	if _, err := fmt.Sscanf(valueStr, "%f", &value); err != nil {
-		return models.Rate{}, true
-	}
-
-	invalidValue := (value <= 0)
+	value, err := strconv.ParseFloat(strings.TrimSpace(cell.V), 64)
+	invalidValue := (err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value))
 	if invalidValue {
-		return models.Rate{}, true
+		return models.Rate{}, true, fmt.Errorf("invalid value %q", cell.V)
 	}
 
 	invertedValue := 1.0 / value
+	// values below ~5e-324 invert to +Inf
+	if math.IsInf(invertedValue, 0) {
+		return models.Rate{}, true, fmt.Errorf("invalid value %q", cell.V)
+	}
 
 	return models.Rate{
 		Base:       base,
@@ -203,7 +275,17 @@ func (p *Provider) parseRate(key string, val any, base string, date time.Time, f
 		Source:     "BankOfCanada",
 		Fetched:    fetchedAt,
 		Calculated: false,
-	}, false
+		Frequency:  models.FrequencyDaily,
+	}, false, nil
+}
+
+// keeps reports readable when a whole object ends up in a cell
+func truncate(raw json.RawMessage) string {
+	const maxLength = 40
+	if len(raw) > maxLength {
+		return string(raw[:maxLength]) + "..."
+	}
+	return string(raw)
 }
 
 // extractCurrency converts BoC series name to currency code (e.g., "FXUSDCAD" -> "USD")
@@ -227,13 +309,85 @@ func isSameDay(t1, t2 time.Time) bool {
 	return y1 == y2 && m1 == m2 && d1 == d2
 }
 
-// TODO: a more dynamic approach could be implemented
 func (p *Provider) SupportedCurrencies() []string {
-	// actively updated currencies as of 2025
-	return []string{
-		"AUD", "BRL", "CNY", "EUR", "HKD", "INR", "IDR",
-		"JPY", "MXN", "NZD", "NOK", "PEN", "RUB", "SAR",
-		"SGD", "ZAR", "KRW", "SEK", "CHF", "TWD", "TRY",
-		"GBP", "USD",
+	return p.currencies.List()
+}
+
+func (p *Provider) SetSupportedCurrencies(currencies []string) {
+	p.currencies.Set(currencies)
+}
+
+// DiscoverCurrencies reads the whole FX_RATES_DAILY group, the series list comes
+// from its seriesDetail and the date range from the observations themselves
+func (p *Provider) DiscoverCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+	url := fmt.Sprintf("%s/observations/group/%s/json", p.baseURL, fxGroup)
+
+	body, err := p.client.Get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("fetching series group from bank of canada: %w", err)
+	}
+
+	var data response
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+
+	return p.parseCatalogue(data), nil
+}
+
+func (p *Provider) parseCatalogue(data response) []models.CurrencyInfo {
+	infos := make(map[string]*models.CurrencyInfo)
+	for seriesName := range data.SeriesDetail {
+		currency := p.extractCurrency(seriesName)
+		if currency == "" {
+			continue
+		}
+		infos[currency] = &models.CurrencyInfo{Source: "BankOfCanada", Currency: currency}
+	}
+
+	for _, obs := range data.Observations {
+		date, skip, err := p.parseDate(obs, time.Time{})
+		if err != nil || skip {
+			continue
+		}
+
+		for key, raw := range obs {
+			info, known := infos[p.extractCurrency(key)]
+			if !known {
+				continue
+			}
+
+			var cell observedValue
+			hasValue := json.Unmarshal(raw, &cell) == nil && cell.V != ""
+			if !hasValue {
+				continue
+			}
+
+			if info.FirstDate.IsZero() || date.Before(info.FirstDate) {
+				info.FirstDate = date
+			}
+			if date.After(info.LastDate) {
+				info.LastDate = date
+			}
+		}
+	}
+
+	var result []models.CurrencyInfo
+	for _, info := range infos {
+		result = append(result, *info)
 	}
+
+	slices.SortFunc(result, func(a, b models.CurrencyInfo) int {
+		return strings.Compare(a.Currency, b.Currency)
+	})
+
+	return result
+}
+
+// fallback until the live catalogue has been discovered, actively updated as of 2025
+var defaultCurrencies = []string{
+	"AUD", "BRL", "CNY", "EUR", "HKD", "INR", "IDR",
+	"JPY", "MXN", "NZD", "NOK", "PEN", "RUB", "SAR",
+	"SGD", "ZAR", "KRW", "SEK", "CHF", "TWD", "TRY",
+	"GBP", "USD",
 }
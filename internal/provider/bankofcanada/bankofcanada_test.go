@@ -2,17 +2,22 @@ package bankofcanada
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/replay"
 )
 
+// responses are replayed from testdata, see the replay package to re-record them
 func TestFetchRates(t *testing.T) {
-	p := New()
+	p := New(WithHTTPClient(replay.HTTPClient("testdata")))
 	ctx := context.Background()
 
 	t.Run("direct CAD rates with inversion", func(t *testing.T) {
-		rates, err := p.FetchRates(ctx, models.RateRequest{
+		result, err := p.FetchRates(ctx, models.RateRequest{
 			Base:    "CAD",
 			Targets: []string{"USD", "EUR"},
 		})
@@ -21,6 +26,7 @@ func TestFetchRates(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		rates := result.Rates
 		if len(rates) != 2 {
 			t.Fatalf("got %d rates, want 2", len(rates))
 		}
@@ -38,7 +44,7 @@ func TestFetchRates(t *testing.T) {
 	})
 
 	t.Run("cross rates via CAD", func(t *testing.T) {
-		rates, err := p.FetchRates(ctx, models.RateRequest{
+		result, err := p.FetchRates(ctx, models.RateRequest{
 			Base:    "USD",
 			Targets: []string{"EUR", "JPY"},
 		})
@@ -47,6 +53,7 @@ func TestFetchRates(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		rates := result.Rates
 		if len(rates) != 2 {
 			t.Fatalf("got %d rates, want 2", len(rates))
 		}
@@ -80,3 +87,71 @@ func TestExtractCurrency(t *testing.T) {
 		}
 	}
 }
+
+func TestParseResponseReport(t *testing.T) {
+	p := New()
+
+	sample := `{"observations": [
+		{"d": "2025-10-16", "FXUSDCAD": {"v": "1.4051"}, "FXEURCAD": {"v": "1.6402"}},
+		{"d": "2025-10-17", "FXUSDCAD": {"v": "1,4032"}, "FXEURCAD": {"v": ""}, "FXJPYCAD": 0.009314},
+		{"d": 20251018, "FXUSDCAD": {"v": "1.4032"}}
+	]}`
+
+	var data response
+	if err := json.Unmarshal([]byte(sample), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	rates, report := p.parseResponse(data, "CAD", time.Time{})
+	if len(rates) != 2 || report.Parsed != 2 {
+		t.Fatalf("got %d rates and report %s", len(rates), report)
+	}
+
+	// the blank EUR value is a day without a fixing, not a malformed record
+	want := []string{
+		`observation 2 (JPY): malformed cell 0.009314`,
+		`observation 2 (USD): invalid value "1,4032"`,
+		`observation 3: invalid date 20251018`,
+	}
+
+	var got []string
+	for _, skipped := range report.Skipped {
+		got = append(got, skipped.String())
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("got skipped records %q, want %q", got, want)
+	}
+}
+
+func TestParseCatalogue(t *testing.T) {
+	p := New()
+
+	sample := `{
+		"seriesDetail": {"FXUSDCAD": {"label": "USD/CAD"}, "FXVNDCAD": {"label": "VND/CAD"}},
+		"observations": [
+			{"d": "2017-01-03", "FXUSDCAD": {"v": "1.3435"}, "FXVNDCAD": {"v": "0.000059"}},
+			{"d": "2025-10-17", "FXUSDCAD": {"v": "1.4032"}, "FXVNDCAD": {"v": ""}}
+		]
+	}`
+
+	var data response
+	if err := json.Unmarshal([]byte(sample), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	infos := p.parseCatalogue(data)
+	if len(infos) != 2 {
+		t.Fatalf("got %d currencies, want 2", len(infos))
+	}
+
+	want := map[string]string{"USD": "2025-10-17", "VND": "2017-01-03"}
+	for _, info := range infos {
+		correctFirst := info.FirstDate.Format("2006-01-02") == "2017-01-03"
+		correctLast := info.LastDate.Format("2006-01-02") == want[info.Currency]
+
+		if !correctFirst || !correctLast {
+			t.Errorf("invalid currency: %+v", info)
+		}
+	}
+}
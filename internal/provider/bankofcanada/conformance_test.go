@@ -0,0 +1,71 @@
+package bankofcanada
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+func TestConformance(t *testing.T) {
+	newProvider := func(baseURL string, client *common.HTTPClient) provider.Provider {
+		return New(WithBaseURL(baseURL), WithHTTPClient(client))
+	}
+
+	providertest.RunWith(t, newProvider, fakeValet, []byte(`{"seriesDetail": {}, "observations": []}`),
+		[]byte(`{"observations": [{"d": "2025-10-17", "FXUSDCAD": {"v": "n/a"}}]}`),
+		[]byte(`{"observations": [{"d": "17/10/2025", "FXUSDCAD": {"v": "1.4032"}}]}`),
+		[]byte(`{"observations": [{"d": "2025-10-17", "FXUSDCAD": 1.4032}]}`),
+		[]byte(`{"observations": {"d": "2025-10-17"}}`),
+	)
+
+	t.Run("not modified", func(t *testing.T) {
+		providertest.CheckNotModified(t, newProvider, fakeValet)
+	})
+}
+
+// fakeValet answers observation requests like the Valet API: 404 for any
+// unknown series, an empty observation list for days without data
+func fakeValet(f providertest.Fixture) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dir, format := path.Split(r.URL.Path)
+		isObservations := strings.HasPrefix(dir, "/observations/") && format == "json"
+		if !isObservations {
+			http.NotFound(w, r)
+			return
+		}
+
+		detail := map[string]any{}
+		observation := map[string]any{"d": f.Date.Format("2006-01-02")}
+
+		for _, series := range strings.Split(path.Base(dir), ",") {
+			currency := strings.TrimSuffix(strings.TrimPrefix(series, "FX"), "CAD")
+			value, published := f.Rates[currency]
+			if !published {
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(map[string]string{"message": "Series " + series + " not found."})
+				return
+			}
+
+			// valet quotes CAD per unit of the foreign currency
+			detail[series] = map[string]string{"label": currency + "/CAD"}
+			observation[series] = map[string]string{"v": strconv.FormatFloat(1/value, 'f', -1, 64)}
+		}
+
+		observations := []any{observation}
+		date := r.URL.Query().Get("start_date")
+		wrongDate := (date != "" && date != f.Date.Format("2006-01-02"))
+		if wrongDate {
+			observations = []any{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"seriesDetail": detail, "observations": observations})
+	})
+}
@@ -0,0 +1,31 @@
+package bankofcanada
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+func FuzzParseResponse(f *testing.F) {
+	p := New()
+	f.Add([]byte(`{"observations": [{"d": "2025-10-17", "FXUSDCAD": {"v": "1.4032"}, "FXEURCAD": {"v": "1.6378"}}]}`), int64(0))
+	f.Add([]byte(`{"observations": [{"d": "2025-10-17", "FXUSDCAD": {"v": "4.9e-324"}, "FXEURCAD": {"v": ""}}]}`), int64(0))
+	f.Add([]byte(`{"observations": [{"d": "2025-10-17", "FXUSDCAD": [1.4], "X": null}, {}]}`), time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC).Unix())
+
+	f.Fuzz(func(t *testing.T, body []byte, requestUnix int64) {
+		var data response
+		if err := json.Unmarshal(body, &data); err != nil {
+			return
+		}
+
+		var requestDate time.Time
+		if requestUnix != 0 {
+			requestDate = time.Unix(requestUnix, 0).UTC()
+		}
+
+		rates, report := p.parseResponse(data, "CAD", requestDate)
+		providertest.CheckParsed(t, rates, report)
+	})
+}
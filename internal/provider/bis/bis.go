@@ -0,0 +1,271 @@
+package bis
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/sdmx"
+)
+
+type Provider struct {
+	baseURL string
+	http    *common.HTTPClient
+	client  *sdmx.Client
+}
+
+// lookback for specific-date requests, monthly series are published with a lag
+// so the last two months are fetched to find a monthly observation to fall back to
+const monthlyLookback = 62 * 24 * time.Hour
+
+// the euro area aggregate is preferred over individual member countries
+const euroArea = "XM"
+
+type Option func(*Provider)
+
+// WithBaseURL points the provider at another SDMX api, e.g. a test server
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces the default client, e.g. to add a cache or replay fixtures
+func WithHTTPClient(client *common.HTTPClient) Option {
+	return func(p *Provider) {
+		p.http = client
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		baseURL: "https://stats.bis.org/api/v1",
+		http:    common.NewHTTPClient(60 * time.Second),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.client = sdmx.NewClient(p.baseURL, p.http)
+	return p
+}
+
+func (p *Provider) Name() string {
+	return "BIS"
+}
+
+func (p *Provider) Base() string {
+	return "USD"
+}
+
+func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	isDirectUSD := (req.Base == "USD")
+	if isDirectUSD {
+		return p.fetchDirectRates(ctx, req)
+	}
+
+	return p.fetchCrossRates(ctx, req)
+}
+
+func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	rates, report, err := p.fetchUSDRates(ctx, req.Targets, req.Date, req.IfModified)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	if err := common.ValidateRates(rates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	// fetch both base and targets to calculate cross-rates via USD
+	allCurrencies := append([]string{req.Base}, req.Targets...)
+	// USD is the pivot and has no series of its own
+	allCurrencies = slices.DeleteFunc(allCurrencies, func(c string) bool { return c == "USD" })
+
+	usdRates, report, err := p.fetchUSDRates(ctx, allCurrencies, req.Date, req.IfModified)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	if err := common.ValidateRates(usdRates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating usd rates: %w", err)
+	}
+
+	rates, err := common.CalculateCrossRates(usdRates, req.Base, req.Targets)
+	if err != nil {
+		return models.RateResult{}, fmt.Errorf("calculating cross rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+func (p *Provider) fetchUSDRates(ctx context.Context, currencies []string, date time.Time, ifModified bool) ([]models.Rate, common.ParseReport, error) {
+	q := p.buildQuery(currencies, date)
+	q.IfModified = ifModified
+
+	observations, report, err := p.client.Data(ctx, q, sdmx.FormatCSV)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching from bis: %w", err)
+	}
+	report.Warn(p.Name())
+
+	return p.selectRates(observations, date, time.Now()), report, nil
+}
+
+func (p *Provider) buildQuery(currencies []string, date time.Time) sdmx.Query {
+	// WS_XRU key: FREQ.REF_AREA.CURRENCY.COLLECTION, A is the period average
+	q := sdmx.Query{
+		Flow:   "WS_XRU",
+		Key:    [][]string{{"D", "M"}, nil, currencies, {"A"}},
+		Detail: "dataonly",
+	}
+
+	hasSpecificDate := !date.IsZero()
+	if hasSpecificDate {
+		q.StartPeriod = date.Add(-monthlyLookback).Format("2006-01-02")
+		q.EndPeriod = date.Format("2006-01-02")
+	} else {
+		q.LastNObservations = 1
+	}
+
+	return q
+}
+
+// selectRates picks one observation per currency: a daily observation on the
+// requested day (or the latest one) wins, otherwise the latest monthly average
+func (p *Provider) selectRates(observations []sdmx.Observation, requestDate time.Time, fetchedAt time.Time) []models.Rate {
+	best := make(map[string]models.Rate)
+	bestArea := make(map[string]string)
+	var order []string
+
+	for _, obs := range observations {
+		currency := obs.SeriesKey["CURRENCY"]
+		refArea := obs.SeriesKey["REF_AREA"]
+		frequency := obs.SeriesKey["FREQ"]
+
+		date, ok := periodEnd(obs.TimePeriod, frequency)
+		if !ok {
+			continue
+		}
+
+		value := obs.ScaledValue()
+		invalidValue := (value <= 0)
+		if invalidValue {
+			continue
+		}
+
+		hasSpecificDate := !requestDate.IsZero()
+		isDaily := (frequency == models.FrequencyDaily)
+		wrongDay := hasSpecificDate && isDaily && !isSameDay(date, requestDate)
+		afterRequest := hasSpecificDate && date.After(requestDate) && !isSameMonth(date, requestDate)
+		if wrongDay || afterRequest {
+			continue
+		}
+
+		candidate := models.Rate{
+			Base:       "USD",
+			Target:     currency,
+			Value:      value,
+			Date:       date,
+			Source:     "BIS",
+			Fetched:    fetchedAt,
+			Calculated: false,
+			Frequency:  frequency,
+		}
+
+		current, seen := best[currency]
+		if !seen {
+			order = append(order, currency)
+			best[currency] = candidate
+			bestArea[currency] = refArea
+			continue
+		}
+
+		if p.isBetter(candidate, refArea, current, bestArea[currency]) {
+			best[currency] = candidate
+			bestArea[currency] = refArea
+		}
+	}
+
+	var rates []models.Rate
+	for _, currency := range order {
+		rates = append(rates, best[currency])
+	}
+	return rates
+}
+
+func (p *Provider) isBetter(candidate models.Rate, candidateArea string, current models.Rate, currentArea string) bool {
+	candidateDaily := (candidate.Frequency == models.FrequencyDaily)
+	currentDaily := (current.Frequency == models.FrequencyDaily)
+	if candidateDaily != currentDaily {
+		return candidateDaily
+	}
+
+	if !candidate.Date.Equal(current.Date) {
+		return candidate.Date.After(current.Date)
+	}
+
+	return candidateArea == euroArea && currentArea != euroArea
+}
+
+// periodEnd converts an SDMX time period to a date, monthly averages are
+// dated at the last day of the month they cover
+func periodEnd(period, frequency string) (time.Time, bool) {
+	switch frequency {
+	case models.FrequencyDaily:
+		date, err := time.Parse("2006-01-02", period)
+		return date, err == nil
+	case models.FrequencyMonthly:
+		month, err := time.Parse("2006-01", period)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return month.AddDate(0, 1, -1), true
+	}
+
+	return time.Time{}, false
+}
+
+func isSameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func isSameMonth(t1, t2 time.Time) bool {
+	y1, m1, _ := t1.Date()
+	y2, m2, _ := t2.Date()
+	return y1 == y2 && m1 == m2
+}
+
+func (p *Provider) SupportedCurrencies() []string {
+	// currencies with a WS_XRU series as of 2025, many are monthly only
+	return []string{
+		"AED", "AFN", "ALL", "AMD", "AOA", "ARS", "AUD", "AZN",
+		"BAM", "BBD", "BDT", "BGN", "BHD", "BIF", "BND", "BOB",
+		"BRL", "BSD", "BTN", "BWP", "BYN", "BZD", "CAD", "CDF",
+		"CHF", "CLP", "CNY", "COP", "CRC", "CVE", "CZK", "DJF",
+		"DKK", "DOP", "DZD", "EGP", "ETB", "EUR", "FJD", "GBP",
+		"GEL", "GHS", "GMD", "GNF", "GTQ", "GYD", "HKD", "HNL",
+		"HTG", "HUF", "IDR", "ILS", "INR", "IQD", "IRR", "ISK",
+		"JMD", "JOD", "JPY", "KES", "KGS", "KHR", "KMF", "KRW",
+		"KWD", "KZT", "LAK", "LBP", "LKR", "LRD", "LSL", "LYD",
+		"MAD", "MDL", "MGA", "MKD", "MMK", "MNT", "MOP", "MRU",
+		"MUR", "MVR", "MWK", "MXN", "MYR", "MZN", "NAD", "NGN",
+		"NIO", "NOK", "NPR", "NZD", "OMR", "PAB", "PEN", "PGK",
+		"PHP", "PKR", "PLN", "PYG", "QAR", "RON", "RSD", "RUB",
+		"RWF", "SAR", "SBD", "SCR", "SDG", "SEK", "SGD", "SLE",
+		"SOS", "SRD", "SZL", "THB", "TJS", "TMT", "TND", "TOP",
+		"TRY", "TTD", "TWD", "TZS", "UAH", "UGX", "UYU", "UZS",
+		"VES", "VND", "VUV", "WST", "XAF", "XCD", "XOF", "XPF",
+		"YER", "ZAR", "ZMW",
+	}
+}
@@ -0,0 +1,71 @@
+package bis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/sdmx"
+)
+
+const sampleCSV = `FREQ:Frequency,REF_AREA:Reference area,CURRENCY:Currency,COLLECTION:Collection,TIME_PERIOD:Time period or range,OBS_VALUE:Observation Value
+D: Daily,JP: Japan,JPY: Yen,A: Average of observations through period,2025-10-15,151.2
+M: Monthly,JP: Japan,JPY: Yen,A: Average of observations through period,2025-09,147.9
+M: Monthly,AF: Afghanistan,AFN: Afghani,A: Average of observations through period,2025-08,68.4
+M: Monthly,AF: Afghanistan,AFN: Afghani,A: Average of observations through period,2025-09,67.9
+D: Daily,DE: Germany,EUR: Euro,A: Average of observations through period,2025-10-15,0.861
+D: Daily,XM: Euro area,EUR: Euro,A: Average of observations through period,2025-10-15,0.86
+D: Daily,GB: United Kingdom,GBP: Pound sterling,A: Average of observations through period,2025-10-15,NaN
+`
+
+func TestSelectRates(t *testing.T) {
+	p := New()
+
+	observations, _, err := sdmx.ParseCSV([]byte(sampleCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates := p.selectRates(observations, time.Time{}, time.Now())
+
+	want := map[string]models.Rate{
+		"JPY": {Value: 151.2, Frequency: models.FrequencyDaily, Date: time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+		"AFN": {Value: 67.9, Frequency: models.FrequencyMonthly, Date: time.Date(2025, 9, 30, 0, 0, 0, 0, time.UTC)},
+		"EUR": {Value: 0.86, Frequency: models.FrequencyDaily, Date: time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d", len(rates), len(want))
+	}
+
+	for _, r := range rates {
+		w := want[r.Target]
+		correctBase := r.Base == "USD"
+		correctValue := r.Value == w.Value
+		correctFrequency := r.Frequency == w.Frequency
+		correctDate := r.Date.Equal(w.Date)
+
+		if !correctBase || !correctValue || !correctFrequency || !correctDate {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+}
+
+func TestSelectRatesSpecificDate(t *testing.T) {
+	p := New()
+
+	observations, _, err := sdmx.ParseCSV([]byte(sampleCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no daily JPY on that day, so the monthly average is used instead
+	rates := p.selectRates(observations, time.Date(2025, 10, 14, 0, 0, 0, 0, time.UTC), time.Now())
+
+	for _, r := range rates {
+		isJPY := r.Target == "JPY"
+		if isJPY && r.Frequency != models.FrequencyMonthly {
+			t.Errorf("expected monthly JPY fallback, got %+v", r)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package bis
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+const csvHeader = "FREQ,REF_AREA,CURRENCY,COLLECTION,TIME_PERIOD,OBS_VALUE\n"
+
+func TestConformance(t *testing.T) {
+	newProvider := func(baseURL string, client *common.HTTPClient) provider.Provider {
+		return New(WithBaseURL(baseURL), WithHTTPClient(client))
+	}
+
+	providertest.RunWith(t, newProvider, fakeWSXRU, []byte(csvHeader),
+		[]byte(csvHeader+"D,GB,GBP,A,2025-10-17,NaN\n"),
+		[]byte(csvHeader+"D,GB,GBP,A,17.10.2025,0.7454\n"),
+		[]byte(csvHeader+"W,GB,GBP,A,2025-W42,0.7454\n"),
+		[]byte("FREQ;CURRENCY;OBS_VALUE\nD;GBP;0.7454\n"),
+	)
+
+	t.Run("not modified", func(t *testing.T) {
+		providertest.CheckNotModified(t, newProvider, fakeWSXRU)
+	})
+}
+
+// fakeWSXRU answers WS_XRU queries like stats.bis.org: SDMX-CSV with a daily
+// observation per matching currency, 404 when nothing matches the key or the period
+func fakeWSXRU(f providertest.Fixture) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.Split(path.Base(r.URL.Path), ".")
+		isExchangeRates := strings.HasPrefix(r.URL.Path, "/data/WS_XRU/") && len(key) == 4
+		if !isExchangeRates {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !inPeriod(f.Date, r.URL.Query().Get("startPeriod"), r.URL.Query().Get("endPeriod")) {
+			http.Error(w, "NoRecordsFound", http.StatusNotFound)
+			return
+		}
+
+		var rows strings.Builder
+		for _, currency := range strings.Split(key[2], "+") {
+			value, published := f.Rates[currency]
+			if !published {
+				continue
+			}
+			// the reference area is the country, which the currency code starts with
+			fmt.Fprintf(&rows, "D,%s,%s,A,%s,%s\n",
+				currency[:2], currency, f.Date.Format("2006-01-02"), strconv.FormatFloat(value, 'f', -1, 64))
+		}
+
+		noResults := (rows.Len() == 0)
+		if noResults {
+			http.Error(w, "NoRecordsFound", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.sdmx.data+csv; version=1.0.0; charset=utf-8")
+		fmt.Fprint(w, csvHeader+rows.String())
+	})
+}
+
+func inPeriod(date time.Time, start, end string) bool {
+	day := date.Format("2006-01-02")
+	afterStart := (start == "" || day >= start)
+	beforeEnd := (end == "" || day <= end)
+	return afterStart && beforeEnd
+}
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// cacheEntry holds the validators of the last 200 for a URL, and its body
+// when the cache is kept on disk
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Stored       time.Time `json:"stored"`
+	body         []byte
+}
+
+// responseCache keeps validators in memory, and with a directory also
+// persists them with the raw bodies across restarts
+type responseCache struct {
+	mu      sync.Mutex
+	dir     string
+	entries map[string]cacheEntry
+}
+
+func newResponseCache(dir string) *responseCache {
+	return &responseCache{dir: dir, entries: make(map[string]cacheEntry)}
+}
+
+// cacheKey separates representations of the same URL, e.g. SDMX-CSV and SDMX-JSON
+func cacheKey(url string, headers map[string]string) string {
+	var names []string
+	for name := range headers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	h := sha256.New()
+	h.Write([]byte(url))
+	for _, name := range names {
+		fmt.Fprintf(h, "\n%s: %s", name, headers[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (rc *responseCache) get(key string) (cacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if entry, ok := rc.entries[key]; ok {
+		return entry, true
+	}
+
+	if rc.dir == "" {
+		return cacheEntry{}, false
+	}
+
+	entry, err := rc.load(key)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+
+	rc.entries[key] = entry
+	return entry, true
+}
+
+func (rc *responseCache) put(key string, entry cacheEntry) error {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	hasValidators := (entry.ETag != "" || entry.LastModified != "")
+	if !hasValidators {
+		delete(rc.entries, key)
+		return nil
+	}
+
+	if rc.dir == "" {
+		// without a directory there is nowhere to serve a 304's body from
+		entry.body = nil
+		rc.entries[key] = entry
+		return nil
+	}
+
+	rc.entries[key] = entry
+	return rc.save(key, entry)
+}
+
+func (rc *responseCache) load(key string) (cacheEntry, error) {
+	meta, err := os.ReadFile(filepath.Join(rc.dir, key+".json"))
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(meta, &entry); err != nil {
+		return cacheEntry{}, err
+	}
+
+	entry.body, err = os.ReadFile(filepath.Join(rc.dir, key+".body"))
+	if err != nil {
+		return cacheEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// save writes the body before the metadata, so a crash never leaves
+// validators pointing at a missing or stale body
+func (rc *responseCache) save(key string, entry cacheEntry) error {
+	if err := os.MkdirAll(rc.dir, 0o755); err != nil {
+		return fmt.Errorf("creating cache dir: %w", err)
+	}
+
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding cache entry: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(rc.dir, key+".body"), entry.body); err != nil {
+		return fmt.Errorf("writing cached body: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(rc.dir, key+".json"), meta); err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
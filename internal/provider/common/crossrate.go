@@ -8,7 +8,9 @@ import (
 )
 
 // CalculateCrossRates converts rates from one base currency to another
-// via cross-rate calculation: if X/USD = 1.1 and X/JPY = 130, then USD/JPY = 130/1.1
+// via cross-rate calculation: if X/USD = 1.1 and X/JPY = 130, then USD/JPY = 130/1.1.
+// A target equal to the pivot of sourceRates is the inverse of the base rate.
+// Targets missing from sourceRates are left out, NewRateResult explains them.
 func CalculateCrossRates(sourceRates []models.Rate, base string, targets []string) ([]models.Rate, error) {
 	baseRate := findRate(sourceRates, base)
 	if baseRate == nil {
@@ -18,6 +20,13 @@ func CalculateCrossRates(sourceRates []models.Rate, base string, targets []strin
 	var rates []models.Rate
 	for _, target := range targets {
 		targetRate := findRate(sourceRates, target)
+
+		// the pivot has no row of its own, it is 1 against itself
+		isPivot := (target == baseRate.Base)
+		if isPivot {
+			targetRate = &models.Rate{Target: target, Value: 1, Date: baseRate.Date, Source: baseRate.Source, Frequency: baseRate.Frequency}
+		}
+
 		if targetRate == nil {
 			continue
 		}
@@ -33,6 +42,7 @@ func CalculateCrossRates(sourceRates []models.Rate, base string, targets []strin
 			Source:     targetRate.Source,
 			Fetched:    time.Now(),
 			Calculated: true,
+			Frequency:  coarserFrequency(baseRate.Frequency, targetRate.Frequency),
 		})
 	}
 
@@ -53,3 +63,13 @@ func findRate(rates []models.Rate, currency string) *models.Rate {
 	}
 	return nil
 }
+
+// coarserFrequency picks the less granular of two frequencies,
+// a cross of a daily and a monthly rate is only as fresh as the monthly one
+func coarserFrequency(a, b string) string {
+	isMonthly := (a == models.FrequencyMonthly || b == models.FrequencyMonthly)
+	if isMonthly {
+		return models.FrequencyMonthly
+	}
+	return models.FrequencyDaily
+}
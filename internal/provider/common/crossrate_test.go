@@ -0,0 +1,41 @@
+package common
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestCalculateCrossRates(t *testing.T) {
+	eurRates := []models.Rate{
+		{Base: "EUR", Target: "USD", Value: 1.1681, Source: "ECB", Frequency: models.FrequencyDaily},
+		{Base: "EUR", Target: "PLN", Value: 4.2435, Source: "ECB", Frequency: models.FrequencyDaily},
+	}
+
+	rates, err := CalculateCrossRates(eurRates, "USD", []string{"PLN", "EUR", "JPY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the pivot comes back as the inverse of the base rate, JPY isn't there
+	want := map[string]float64{
+		"PLN": 4.2435 / 1.1681,
+		"EUR": 1 / 1.1681,
+	}
+
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d", len(rates), len(want))
+	}
+
+	for _, r := range rates {
+		correct := r.Base == "USD" && r.Calculated && r.Source == "ECB" && math.Abs(r.Value-want[r.Target]) < 1e-12
+		if !correct {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+
+	if _, err := CalculateCrossRates(eurRates, "GBP", []string{"USD"}); err == nil {
+		t.Error("expected an error for a missing base, got none")
+	}
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"slices"
+	"sync"
+)
+
+// CurrencySet holds a provider's supported currencies, starting from a
+// static list and replaced once the live catalogue has been discovered
+type CurrencySet struct {
+	mu         sync.RWMutex
+	currencies []string
+}
+
+func NewCurrencySet(defaults []string) *CurrencySet {
+	return &CurrencySet{currencies: slices.Clone(defaults)}
+}
+
+func (s *CurrencySet) List() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.currencies)
+}
+
+func (s *CurrencySet) Set(currencies []string) {
+	sorted := slices.Clone(currencies)
+	slices.Sort(sorted)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currencies = sorted
+}
@@ -1,45 +1,436 @@
 package common
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// errors a request can end in, test with errors.Is; HTTPError carries the details
+var (
+	// ErrNotFound means the source has nothing at that address, e.g. no data for the day
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited means the source kept throttling us after all retries
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUpstream means the source is failing or unreachable, worth trying again later
+	ErrUpstream = errors.New("upstream unavailable")
+	// ErrNotModified means the source has nothing new since the last response for the request
+	ErrNotModified = errors.New("not modified")
+)
+
+// Response is the outcome of a conditional Fetch
+type Response struct {
+	// Body is the new content, or the cached one when NotModified and the
+	// client keeps a cache directory
+	Body []byte
+	// NotModified is set when the server answered 304 to our validators
+	NotModified  bool
+	ETag         string
+	LastModified string
+}
+
+const (
+	DefaultMaxResponseSize = 64 << 20
+	// how much of an error body ends up in the message
+	maxErrorBody = 256
+)
+
+type RetryPolicy struct {
+	// MaxAttempts counts the first try, 1 disables retries
+	MaxAttempts int
+	// BaseDelay doubles with every retry, with jitter, up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+// central banks publish a handful of files a day, a few requests a second is plenty
+const (
+	DefaultRequestsPerSecond = 4
+	DefaultBurst             = 4
+)
+
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Body       string
+	kind       error
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.kind
+}
+
 type HTTPClient struct {
-	client *http.Client
+	client          *http.Client
+	retry           RetryPolicy
+	maxResponseSize int64
+	cache           *responseCache
+
+	requestsPerSecond float64
+	burst             int
+	mu                sync.Mutex
+	limiters          map[string]*limiter
+}
+
+type HTTPOption func(*HTTPClient)
+
+func WithRetry(policy RetryPolicy) HTTPOption {
+	return func(c *HTTPClient) {
+		c.retry = policy
+	}
+}
+
+// WithRateLimit caps requests per host, zero disables the limit
+func WithRateLimit(requestsPerSecond float64, burst int) HTTPOption {
+	return func(c *HTTPClient) {
+		c.requestsPerSecond = requestsPerSecond
+		c.burst = burst
+	}
+}
+
+// WithTransport swaps the round tripper, e.g. for recorded responses in tests
+func WithTransport(transport http.RoundTripper) HTTPOption {
+	return func(c *HTTPClient) {
+		c.client.Transport = transport
+	}
+}
+
+// WithCacheDir keeps validators and raw responses on disk, so 304s can be
+// answered from the cache and survive restarts
+func WithCacheDir(dir string) HTTPOption {
+	return func(c *HTTPClient) {
+		c.cache = newResponseCache(dir)
+	}
+}
+
+func WithMaxResponseSize(bytes int64) HTTPOption {
+	return func(c *HTTPClient) {
+		c.maxResponseSize = bytes
+	}
 }
 
-func NewHTTPClient(timeout time.Duration) *HTTPClient {
-	return &HTTPClient{
-		client: &http.Client{Timeout: timeout},
+// MaxResponseSize is the most bytes a response body may hold, archives
+// unpacked from a response are held to it as well
+func (c *HTTPClient) MaxResponseSize() int64 {
+	if c.maxResponseSize <= 0 {
+		return DefaultMaxResponseSize
+	}
+	return c.maxResponseSize
+}
+
+func NewHTTPClient(timeout time.Duration, opts ...HTTPOption) *HTTPClient {
+	c := &HTTPClient{
+		client:            &http.Client{Timeout: timeout},
+		retry:             DefaultRetryPolicy,
+		maxResponseSize:   DefaultMaxResponseSize,
+		requestsPerSecond: DefaultRequestsPerSecond,
+		burst:             DefaultBurst,
+		limiters:          make(map[string]*limiter),
+		cache:             newResponseCache(""),
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	return c
 }
 
 func (c *HTTPClient) Get(ctx context.Context, url string) ([]byte, error) {
+	return c.GetWithHeaders(ctx, url, nil)
+}
+
+// GetWithHeaders is Get with extra request headers, e.g. Accept for content negotiation.
+// It only revalidates when a cached body can stand in for a 304.
+func (c *HTTPClient) GetWithHeaders(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	resp, err := c.fetch(ctx, url, headers, true)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Fetch sends the validators of the last response for the URL, if any, so
+// pollers can skip parsing when Response.NotModified is set
+func (c *HTTPClient) Fetch(ctx context.Context, url string, headers map[string]string) (*Response, error) {
+	return c.fetch(ctx, url, headers, false)
+}
+
+// FetchBody is GetWithHeaders, or with ifModified a Fetch whose 304 ends in
+// ErrNotModified, for callers that already hold the last body
+func (c *HTTPClient) FetchBody(ctx context.Context, url string, headers map[string]string, ifModified bool) ([]byte, error) {
+	if !ifModified {
+		return c.GetWithHeaders(ctx, url, headers)
+	}
+
+	resp, err := c.Fetch(ctx, url, headers)
+	if err != nil {
+		return nil, err
+	}
+	if resp.NotModified {
+		return nil, ErrNotModified
+	}
+	return resp.Body, nil
+}
+
+// fetch retries network errors, 429 and 5xx with backoff, honouring Retry-After
+func (c *HTTPClient) fetch(ctx context.Context, url string, headers map[string]string, needsBody bool) (*Response, error) {
+	key := cacheKey(url, headers)
+	cached, hasCached := c.cache.get(key)
+
+	canRevalidate := hasCached && (!needsBody || cached.body != nil)
+	if !canRevalidate {
+		cached = cacheEntry{}
+	}
+
+	attempts := max(c.retry.MaxAttempts, 1)
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err := c.waitForHost(ctx, url); err != nil {
+			return nil, err
+		}
+
+		resp, retryAfter, err := c.do(ctx, url, headers, cached)
+		if err == nil {
+			return c.remember(key, url, resp, cached)
+		}
+		lastErr = err
+
+		isRetryable := errors.Is(err, ErrUpstream) || errors.Is(err, ErrRateLimited)
+		isLastAttempt := (attempt == attempts-1)
+		if !isRetryable || isLastAttempt {
+			break
+		}
+
+		delay := max(c.backoff(attempt), retryAfter)
+		waitsTooLong := (c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay)
+		if waitsTooLong {
+			break
+		}
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+// remember stores the validators of a 200, or fills a 304 in from the cache
+func (c *HTTPClient) remember(key, url string, resp *Response, cached cacheEntry) (*Response, error) {
+	if resp.NotModified {
+		resp.Body = cached.body
+		resp.ETag = cmp.Or(resp.ETag, cached.ETag)
+		resp.LastModified = cmp.Or(resp.LastModified, cached.LastModified)
+		return resp, nil
+	}
+
+	entry := cacheEntry{
+		URL:          url,
+		ETag:         resp.ETag,
+		LastModified: resp.LastModified,
+		Stored:       time.Now(),
+		body:         resp.Body,
+	}
+	if err := c.cache.put(key, entry); err != nil {
+		return nil, fmt.Errorf("caching response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// do makes one attempt, returning the Retry-After delay the server asked for
+func (c *HTTPClient) do(ctx context.Context, url string, headers map[string]string, cached cacheEntry) (*Response, time.Duration, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
 	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("executing request: %w", err)
+		isCancelled := (ctx.Err() != nil)
+		if isCancelled {
+			return nil, 0, fmt.Errorf("executing request: %w", err)
+		}
+		return nil, 0, fmt.Errorf("executing request: %w", errors.Join(ErrUpstream, err))
 	}
 	defer resp.Body.Close()
 
+	result := &Response{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	isNotModified := (resp.StatusCode == http.StatusNotModified)
+	if isNotModified {
+		result.NotModified = true
+		return result, 0, nil
+	}
+
 	success := (resp.StatusCode == http.StatusOK)
 	if !success {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("http %d: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		httpErr := &HTTPError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			kind:       classifyStatus(resp.StatusCode),
+		}
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), httpErr
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	limit := c.MaxResponseSize()
+	result.Body, err = io.ReadAll(io.LimitReader(resp.Body, limit+1))
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+		return nil, 0, fmt.Errorf("reading response: %w", errors.Join(ErrUpstream, err))
+	}
+
+	tooLarge := (int64(len(result.Body)) > limit)
+	if tooLarge {
+		return nil, 0, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+
+	return result, 0, nil
+}
+
+func classifyStatus(status int) error {
+	switch {
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case status >= 500:
+		return ErrUpstream
+	}
+	return nil
+}
+
+// backoff doubles per attempt with jitter in the upper half, so clients don't retry in lockstep
+func (c *HTTPClient) backoff(attempt int) time.Duration {
+	delay := c.retry.BaseDelay << attempt
+	overflowed := (delay < c.retry.BaseDelay)
+	exceedsMax := (c.retry.MaxDelay > 0 && delay > c.retry.MaxDelay)
+	if overflowed || exceedsMax {
+		delay = c.retry.MaxDelay
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half)
+}
+
+// parseRetryAfter reads either delay-seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return 0
+}
+
+func (c *HTTPClient) waitForHost(ctx context.Context, rawURL string) error {
+	noLimit := (c.requestsPerSecond <= 0)
+	if noLimit {
+		return nil
+	}
+
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	c.mu.Lock()
+	l, ok := c.limiters[host]
+	if !ok {
+		l = newLimiter(c.requestsPerSecond, c.burst)
+		c.limiters[host] = l
 	}
+	c.mu.Unlock()
 
-	return body, nil
+	return sleep(ctx, l.reserve())
+}
+
+// limiter is a token bucket, reserve takes a token and says how long to wait for it
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	b := float64(max(burst, 1))
+	return &limiter{rate: rate, burst: b, tokens: b, last: time.Now()}
+}
+
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	// tokens may go negative, later callers then queue behind earlier ones
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
@@ -0,0 +1,231 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+func TestHTTPClientRetries(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		client := NewHTTPClient(time.Second, WithRetry(fastRetry))
+		body, err := client.Get(ctx, server.URL)
+		if err != nil || string(body) != "ok" || calls.Load() != 3 {
+			t.Errorf("got %q, %v after %d calls", body, err, calls.Load())
+		}
+	})
+
+	t.Run("gives up as upstream error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPClient(time.Second, WithRetry(fastRetry)).Get(ctx, server.URL)
+
+		var httpErr *HTTPError
+		correct := errors.Is(err, ErrUpstream) && errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusBadGateway
+		if !correct {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("not found is not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.Error(w, strings.Repeat("no results ", 100), http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPClient(time.Second, WithRetry(fastRetry)).Get(ctx, server.URL)
+		if !errors.Is(err, ErrNotFound) || calls.Load() != 1 {
+			t.Errorf("got %v after %d calls", err, calls.Load())
+		}
+		if len(err.Error()) > maxErrorBody+32 {
+			t.Errorf("error body not truncated: %d bytes", len(err.Error()))
+		}
+	})
+
+	t.Run("honours retry-after", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := NewHTTPClient(time.Second, WithRetry(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second})).Get(ctx, server.URL)
+		if err != nil || time.Since(start) < time.Second {
+			t.Errorf("got %v after %s", err, time.Since(start))
+		}
+	})
+
+	t.Run("retry-after beyond max delay gives up", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		_, err := NewHTTPClient(time.Second, WithRetry(fastRetry)).Get(ctx, server.URL)
+		if !errors.Is(err, ErrRateLimited) {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+}
+
+func TestHTTPClientLimits(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("x", 1024)))
+	}))
+	defer server.Close()
+
+	t.Run("max response size", func(t *testing.T) {
+		_, err := NewHTTPClient(time.Second, WithMaxResponseSize(512)).Get(ctx, server.URL)
+		if err == nil || !strings.Contains(err.Error(), "exceeds") {
+			t.Errorf("unexpected error %v", err)
+		}
+	})
+
+	t.Run("rate limit per host", func(t *testing.T) {
+		client := NewHTTPClient(time.Second, WithRateLimit(20, 1))
+
+		start := time.Now()
+		for range 3 {
+			if _, err := client.Get(ctx, server.URL); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// the first request takes the burst, the other two wait 50ms each
+		if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+			t.Errorf("three requests took only %s", elapsed)
+		}
+	})
+}
+
+func TestConditionalGet(t *testing.T) {
+	ctx := context.Background()
+
+	var full atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Fri, 17 Oct 2025 14:00:00 GMT")
+
+		isFresh := (r.Header.Get("If-None-Match") == `"v1"`)
+		if isFresh {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		full.Add(1)
+		w.Write([]byte("rates"))
+	}))
+	defer server.Close()
+
+	t.Run("fetch reports not modified", func(t *testing.T) {
+		client := NewHTTPClient(time.Second)
+
+		first, err := client.Fetch(ctx, server.URL, nil)
+		if err != nil || first.NotModified || string(first.Body) != "rates" {
+			t.Fatalf("got %+v, %v", first, err)
+		}
+
+		second, err := client.Fetch(ctx, server.URL, nil)
+		if err != nil || !second.NotModified || second.ETag != `"v1"` {
+			t.Errorf("got %+v, %v", second, err)
+		}
+	})
+
+	t.Run("get without a cache dir always downloads", func(t *testing.T) {
+		client := NewHTTPClient(time.Second)
+		before := full.Load()
+
+		for range 2 {
+			body, err := client.Get(ctx, server.URL)
+			if err != nil || string(body) != "rates" {
+				t.Fatalf("got %q, %v", body, err)
+			}
+		}
+
+		if downloads := full.Load() - before; downloads != 2 {
+			t.Errorf("got %d full downloads, want 2", downloads)
+		}
+	})
+
+	t.Run("cache dir serves 304s across clients", func(t *testing.T) {
+		dir := t.TempDir()
+		before := full.Load()
+
+		for range 2 {
+			client := NewHTTPClient(time.Second, WithCacheDir(dir))
+
+			body, err := client.Get(ctx, server.URL)
+			if err != nil || string(body) != "rates" {
+				t.Fatalf("got %q, %v", body, err)
+			}
+
+			resp, err := client.Fetch(ctx, server.URL, nil)
+			if err != nil || !resp.NotModified || string(resp.Body) != "rates" {
+				t.Errorf("got %+v, %v", resp, err)
+			}
+		}
+
+		if downloads := full.Load() - before; downloads != 1 {
+			t.Errorf("got %d full downloads, want 1", downloads)
+		}
+	})
+
+	t.Run("fetch body turns a 304 into an error when asked to", func(t *testing.T) {
+		client := NewHTTPClient(time.Second)
+
+		body, err := client.FetchBody(ctx, server.URL, nil, true)
+		if err != nil || string(body) != "rates" {
+			t.Fatalf("got %q, %v", body, err)
+		}
+
+		if _, err := client.FetchBody(ctx, server.URL, nil, true); !errors.Is(err, ErrNotModified) {
+			t.Errorf("expected ErrNotModified, got %v", err)
+		}
+
+		// without ifModified the body is always there
+		body, err = client.FetchBody(ctx, server.URL, nil, false)
+		if err != nil || string(body) != "rates" {
+			t.Errorf("got %q, %v", body, err)
+		}
+	})
+
+	t.Run("representations are cached separately", func(t *testing.T) {
+		csv := cacheKey(server.URL, map[string]string{"Accept": "text/csv"})
+		json := cacheKey(server.URL, map[string]string{"Accept": "application/json"})
+		if csv == json {
+			t.Error("accept header should be part of the cache key")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package common
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// examples carried by a warning, the full hist zip can skip thousands of records
+const maxWarnedRecords = 5
+
+// ParseReport accounts for the records a parser could not use, so an upstream
+// format change shows up as a warning instead of quietly missing currencies.
+// Values the source leaves empty on purpose (N/A, NaN, blanks) are not malformed.
+type ParseReport struct {
+	// Parsed counts the records that made it into the result
+	Parsed  int
+	Skipped []SkippedRecord
+}
+
+type SkippedRecord struct {
+	// Record locates the record in the payload, e.g. "line 4" or "observation 2"
+	Record string
+	// Currency is set when the record could be attributed to one
+	Currency string
+	Reason   string
+}
+
+func (s SkippedRecord) String() string {
+	hasCurrency := (s.Currency != "")
+	if hasCurrency {
+		return fmt.Sprintf("%s (%s): %s", s.Record, s.Currency, s.Reason)
+	}
+	return fmt.Sprintf("%s: %s", s.Record, s.Reason)
+}
+
+func (r *ParseReport) Skip(record, currency, reason string) {
+	r.Skipped = append(r.Skipped, SkippedRecord{Record: record, Currency: currency, Reason: reason})
+}
+
+// Chain follows a report with the one of a later parsing stage: skipped
+// records add up, the parsed count is the later stage's
+func (r ParseReport) Chain(next ParseReport) ParseReport {
+	return ParseReport{
+		Parsed:  next.Parsed,
+		Skipped: append(slices.Clone(r.Skipped), next.Skipped...),
+	}
+}
+
+func (r ParseReport) Clean() bool {
+	return len(r.Skipped) == 0
+}
+
+// SkippedCurrencies lists the currencies that lost at least one record, sorted
+func (r ParseReport) SkippedCurrencies() []string {
+	var currencies []string
+	for _, s := range r.Skipped {
+		if s.Currency != "" {
+			currencies = append(currencies, s.Currency)
+		}
+	}
+
+	slices.Sort(currencies)
+	return slices.Compact(currencies)
+}
+
+func (r ParseReport) String() string {
+	if r.Clean() {
+		return fmt.Sprintf("parsed %d records", r.Parsed)
+	}
+
+	var examples []string
+	for _, s := range r.Skipped[:min(len(r.Skipped), maxWarnedRecords)] {
+		examples = append(examples, s.String())
+	}
+
+	hasMore := (len(r.Skipped) > maxWarnedRecords)
+	if hasMore {
+		examples = append(examples, fmt.Sprintf("and %d more", len(r.Skipped)-maxWarnedRecords))
+	}
+
+	return fmt.Sprintf("parsed %d records, skipped %d: %s", r.Parsed, len(r.Skipped), strings.Join(examples, "; "))
+}
+
+// Warn logs the skipped records of a source's response, if there are any
+func (r ParseReport) Warn(source string) {
+	if r.Clean() {
+		return
+	}
+
+	slog.Warn("skipped malformed records",
+		"source", source,
+		"parsed", r.Parsed,
+		"skipped", len(r.Skipped),
+		"currencies", r.SkippedCurrencies(),
+		"report", r.String(),
+	)
+}
+
+// skipReasons joins what was skipped for one currency
+func (r ParseReport) skipReasons(currency string) string {
+	var reasons []string
+	for _, s := range r.Skipped {
+		if s.Currency == currency {
+			reasons = append(reasons, s.Record+": "+s.Reason)
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"slices"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// NewRateResult explains each requested target: returned when a rate was
+// found, a parse error when the report skipped a record of it, otherwise
+// unsupported or not published depending on whether the source carries it
+func NewRateResult(targets []string, rates []models.Rate, supported []string, report ParseReport) models.RateResult {
+	found := make(map[string]bool, len(rates))
+	for _, rate := range rates {
+		found[rate.Target] = true
+	}
+
+	result := models.RateResult{Rates: rates}
+	for _, target := range targets {
+		outcome := models.TargetResult{Currency: target}
+
+		malformed := slices.Contains(report.SkippedCurrencies(), target)
+		switch {
+		case found[target]:
+			outcome.Status = models.StatusReturned
+		case malformed:
+			outcome.Status = models.StatusParseError
+			outcome.Detail = report.skipReasons(target)
+		case !slices.Contains(supported, target):
+			outcome.Status = models.StatusUnsupported
+		default:
+			outcome.Status = models.StatusNotPublished
+		}
+
+		result.Targets = append(result.Targets, outcome)
+	}
+
+	return result
+}
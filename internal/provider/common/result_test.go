@@ -0,0 +1,39 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestNewRateResult(t *testing.T) {
+	rates := []models.Rate{{Base: "EUR", Target: "USD", Value: 1.17}}
+	supported := []string{"USD", "JPY", "GBP"}
+
+	var report ParseReport
+	report.Skip("row 3", "GBP", "invalid value \"1,2\"")
+
+	result := NewRateResult([]string{"USD", "JPY", "GBP", "XXX"}, rates, supported, report)
+
+	want := []models.TargetResult{
+		{Currency: "USD", Status: models.StatusReturned},
+		{Currency: "JPY", Status: models.StatusNotPublished},
+		{Currency: "GBP", Status: models.StatusParseError, Detail: "row 3: invalid value \"1,2\""},
+		{Currency: "XXX", Status: models.StatusUnsupported},
+	}
+
+	correctCount := (len(result.Targets) == len(want))
+	if !correctCount {
+		t.Fatalf("got %d outcomes, want %d", len(result.Targets), len(want))
+	}
+
+	for i, outcome := range result.Targets {
+		if outcome != want[i] {
+			t.Errorf("got %s, want %s", outcome, want[i])
+		}
+	}
+
+	if missing := result.Missing(); len(missing) != 3 {
+		t.Errorf("got %d missing targets, want 3", len(missing))
+	}
+}
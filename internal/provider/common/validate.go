@@ -2,6 +2,7 @@ package common
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/xhos/fxgo/internal/models"
@@ -22,7 +23,7 @@ func ValidateRates(rates []models.Rate) error {
 }
 
 func validateRate(index int, rate models.Rate) error {
-	invalidValue := rate.Value <= 0
+	invalidValue := rate.Value <= 0 || math.IsNaN(rate.Value) || math.IsInf(rate.Value, 0)
 	if invalidValue {
 		return fmt.Errorf("rate[%d]: invalid value %.4f", index, rate.Value)
 	}
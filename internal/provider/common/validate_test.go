@@ -1,6 +1,7 @@
 package common
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -23,6 +24,8 @@ func TestValidateRates(t *testing.T) {
 		"empty":           {},
 		"negative value":  {{Base: "EUR", Target: "USD", Value: -1, Date: now, Source: "ECB"}},
 		"zero value":      {{Base: "EUR", Target: "USD", Value: 0, Date: now, Source: "ECB"}},
+		"nan value":       {{Base: "EUR", Target: "USD", Value: math.NaN(), Date: now, Source: "ECB"}},
+		"infinite value":  {{Base: "EUR", Target: "USD", Value: math.Inf(1), Date: now, Source: "ECB"}},
 		"self conversion": {{Base: "EUR", Target: "EUR", Value: 1, Date: now, Source: "ECB"}},
 		"empty base":      {{Base: "", Target: "USD", Value: 1, Date: now, Source: "ECB"}},
 		"future date":     {{Base: "EUR", Target: "USD", Value: 1, Date: now.Add(48 * time.Hour), Source: "ECB"}},
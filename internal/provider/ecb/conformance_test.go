@@ -0,0 +1,71 @@
+package ecb
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+const csvHeader = "KEY,FREQ,CURRENCY,CURRENCY_DENOM,EXR_TYPE,EXR_SUFFIX,TIME_PERIOD,OBS_VALUE,OBS_STATUS,UNIT_MULT\n"
+
+func TestConformance(t *testing.T) {
+	newProvider := func(baseURL string, client *common.HTTPClient) provider.Provider {
+		return New(WithBaseURL(baseURL), WithReferenceBaseURL(baseURL), WithHTTPClient(client))
+	}
+
+	providertest.RunWith(t, newProvider, fakeDataAPI, []byte(csvHeader),
+		[]byte(csvHeader+"EXR.D.USD.EUR.SP00.A,D,USD,EUR,SP00,A,2025-10-17,n/a,A,0\n"),
+		[]byte(csvHeader+"EXR.D.USD.EUR.SP00.A,D,USD,EUR,SP00,A,17.10.2025,1.1681,A,0\n"),
+		[]byte("TIME_PERIOD;OBS_VALUE\n2025-10-17;1.1681\n"),
+	)
+
+	t.Run("not modified", func(t *testing.T) {
+		providertest.CheckNotModified(t, newProvider, fakeDataAPI)
+	})
+}
+
+// fakeDataAPI answers EXR queries like data-api.ecb.europa.eu: SDMX-CSV with a
+// row per matching series, 404 when nothing matches the key or the period
+func fakeDataAPI(f providertest.Fixture) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.Split(path.Base(r.URL.Path), ".")
+		isExchangeRates := strings.HasPrefix(r.URL.Path, "/data/EXR/") && len(key) == 5
+		if !isExchangeRates {
+			http.NotFound(w, r)
+			return
+		}
+
+		period := r.URL.Query().Get("startPeriod")
+		wrongPeriod := (period != "" && period != f.Date.Format("2006-01-02"))
+		if wrongPeriod {
+			http.Error(w, "No results found.", http.StatusNotFound)
+			return
+		}
+
+		var rows strings.Builder
+		for _, currency := range strings.Split(key[1], "+") {
+			value, published := f.Rates[currency]
+			if !published {
+				continue
+			}
+			fmt.Fprintf(&rows, "EXR.D.%s.EUR.SP00.A,D,%s,EUR,SP00,A,%s,%s,A,0\n",
+				currency, currency, f.Date.Format("2006-01-02"), strconv.FormatFloat(value, 'f', -1, 64))
+		}
+
+		noResults := (rows.Len() == 0)
+		if noResults {
+			http.Error(w, "No results found.", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/vnd.sdmx.data+csv; version=1.0.0; charset=utf-8")
+		fmt.Fprint(w, csvHeader+rows.String())
+	})
+}
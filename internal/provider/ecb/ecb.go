@@ -2,33 +2,83 @@ package ecb
 
 import (
 	"context"
-	"encoding/csv"
+	"errors"
 	"fmt"
-	"strconv"
-	"strings"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/xhos/fxgo/internal/models"
 	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/sdmx"
 )
 
 type Provider struct {
-	baseURL string
-	client  *common.HTTPClient
+	feed             Feed
+	dataAPIURL       string
+	referenceBaseURL string
+	http             *common.HTTPClient
+	client           *sdmx.Client
+	currencies       *common.CurrencySet
 }
 
-func New() *Provider {
-	return &Provider{
-		baseURL: "https://data-api.ecb.europa.eu",
-		client:  common.NewHTTPClient(30 * time.Second),
+type Option func(*Provider)
+
+// WithFeed selects the transport, the reference files are always used as a
+// fallback when the data-api fails
+func WithFeed(feed Feed) Option {
+	return func(p *Provider) {
+		p.feed = feed
+	}
+}
+
+// WithBaseURL points the provider at another data-api, e.g. a test server
+func WithBaseURL(dataAPIURL string) Option {
+	return func(p *Provider) {
+		p.dataAPIURL = dataAPIURL
+	}
+}
+
+// WithReferenceBaseURL points the provider at another copy of the eurofxref files
+func WithReferenceBaseURL(referenceBaseURL string) Option {
+	return func(p *Provider) {
+		p.referenceBaseURL = referenceBaseURL
+	}
+}
+
+// WithHTTPClient replaces the default client, e.g. to add a cache or replay fixtures
+func WithHTTPClient(client *common.HTTPClient) Option {
+	return func(p *Provider) {
+		p.http = client
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		feed:             FeedDataAPI,
+		dataAPIURL:       "https://data-api.ecb.europa.eu/service",
+		referenceBaseURL: "https://www.ecb.europa.eu/stats/eurofxref",
+		http:             common.NewHTTPClient(30 * time.Second),
+		currencies:       common.NewCurrencySet(defaultCurrencies),
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	p.client = sdmx.NewClient(p.dataAPIURL, p.http)
+	return p
 }
 
 func (p *Provider) Name() string {
 	return "ECB"
 }
 
-func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
+func (p *Provider) Base() string {
+	return "EUR"
+}
+
+func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
 	isDirectEUR := (req.Base == "EUR")
 	if isDirectEUR {
 		return p.fetchDirectRates(ctx, req)
@@ -37,139 +87,181 @@ func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) ([]mo
 	return p.fetchCrossRates(ctx, req)
 }
 
-func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
-	url := p.buildURL(req.Targets, req.Date)
-
-	body, err := p.client.Get(ctx, url)
+func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	rates, report, err := p.fetchEURRates(ctx, req.Targets, req.Date, req.IfModified)
 	if err != nil {
-		return nil, fmt.Errorf("fetching from ecb: %w", err)
-	}
-
-	rates, err := p.parseCSV(body, "EUR", req.Date)
-	if err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return models.RateResult{}, err
 	}
 
 	if err := common.ValidateRates(rates); err != nil {
-		return nil, fmt.Errorf("validating rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("validating rates: %w", err)
 	}
 
-	return rates, nil
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
 }
 
-func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error) {
+func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
 	// fetch both base and targets to calculate cross-rates via EUR
 	allCurrencies := append([]string{req.Base}, req.Targets...)
-	url := p.buildURL(allCurrencies, req.Date)
-
-	body, err := p.client.Get(ctx, url)
-	if err != nil {
-		return nil, fmt.Errorf("fetching from ecb: %w", err)
-	}
+	// EUR is the pivot and has no series of its own
+	allCurrencies = slices.DeleteFunc(allCurrencies, func(c string) bool { return c == "EUR" })
 
-	eurRates, err := p.parseCSV(body, "EUR", req.Date)
+	eurRates, report, err := p.fetchEURRates(ctx, allCurrencies, req.Date, req.IfModified)
 	if err != nil {
-		return nil, fmt.Errorf("parsing response: %w", err)
+		return models.RateResult{}, err
 	}
 
 	if err := common.ValidateRates(eurRates); err != nil {
-		return nil, fmt.Errorf("validating eur rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("validating eur rates: %w", err)
 	}
 
 	rates, err := common.CalculateCrossRates(eurRates, req.Base, req.Targets)
 	if err != nil {
-		return nil, fmt.Errorf("calculating cross rates: %w", err)
+		return models.RateResult{}, fmt.Errorf("calculating cross rates: %w", err)
 	}
 
-	return rates, nil
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
 }
 
-func (p *Provider) buildURL(currencies []string, date time.Time) string {
-	currencyList := strings.Join(currencies, "+")
-	seriesKey := fmt.Sprintf("D.%s.EUR.SP00.A", currencyList)
+// fetchEURRates reads through the configured feed, falling back to the reference files
+func (p *Provider) fetchEURRates(ctx context.Context, currencies []string, date time.Time, ifModified bool) ([]models.Rate, common.ParseReport, error) {
+	usesDataAPI := (p.feed == FeedDataAPI)
+	if !usesDataAPI {
+		return p.fetchReferenceRates(ctx, p.feed, currencies, date, ifModified)
+	}
 
-	url := fmt.Sprintf("%s/service/data/EXR/%s?format=csvdata", p.baseURL, seriesKey)
+	q := p.buildQuery(currencies, date)
+	q.IfModified = ifModified
 
-	hasSpecificDate := !date.IsZero()
-	if hasSpecificDate {
-		dateStr := date.Format("2006-01-02")
-		url += fmt.Sprintf("&startPeriod=%s&endPeriod=%s", dateStr, dateStr)
-	} else {
-		url += "&lastNObservations=1"
+	observations, decoded, err := p.client.Data(ctx, q, sdmx.FormatCSV)
+	if err == nil {
+		rates, parsed := p.parseObservations(observations, "EUR", date)
+		report := decoded.Chain(parsed)
+		report.Warn(p.Name())
+		return rates, report, nil
 	}
 
-	return url
+	// the data-api answers 404 for days without a fixing, the files won't have them either
+	isNoData := errors.Is(err, common.ErrNotFound)
+	if isNoData {
+		return nil, common.ParseReport{}, fmt.Errorf("no ecb rates for %s: %w", date.Format("2006-01-02"), err)
+	}
+
+	isNotModified := errors.Is(err, common.ErrNotModified)
+	if isNotModified {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching from ecb: %w", err)
+	}
+
+	rates, report, fallbackErr := p.fetchReferenceRates(ctx, feedFor(date), currencies, date, false)
+	if fallbackErr != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching from ecb: %w", errors.Join(err, fallbackErr))
+	}
+
+	return rates, report, nil
 }
 
-func (p *Provider) parseCSV(data []byte, base string, requestDate time.Time) ([]models.Rate, error) {
-	reader := csv.NewReader(strings.NewReader(string(data)))
-	records, err := reader.ReadAll()
+func (p *Provider) fetchReferenceRates(ctx context.Context, feed Feed, currencies []string, date time.Time, ifModified bool) ([]models.Rate, common.ParseReport, error) {
+	body, err := p.http.FetchBody(ctx, p.referenceURL(feed), nil, ifModified)
 	if err != nil {
-		return nil, fmt.Errorf("reading csv: %w", err)
+		return nil, common.ParseReport{}, fmt.Errorf("fetching %s feed from ecb: %w", feed, err)
 	}
 
-	noData := (len(records) < 2)
-	if noData {
-		return nil, fmt.Errorf("no data in response")
+	rates, report, err := p.parseFeed(feed, body)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("parsing %s feed: %w", feed, err)
 	}
+	report.Warn(p.Name())
+
+	return filterTargets(selectDate(rates, date), currencies), report, nil
+}
+
+// FetchHistory downloads the full reference rate history in one request,
+// meant for first-time backfills
+func (p *Provider) FetchHistory(ctx context.Context) ([]models.Rate, error) {
+	body, err := p.http.Get(ctx, p.referenceURL(FeedHist))
+	if err != nil {
+		return nil, fmt.Errorf("fetching history from ecb: %w", err)
+	}
+
+	rates, report, err := p.parseHistZip(body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing history: %w", err)
+	}
+	report.Warn(p.Name())
+
+	if err := common.ValidateRates(rates); err != nil {
+		return nil, fmt.Errorf("validating history: %w", err)
+	}
+
+	return rates, nil
+}
 
-	header := records[0]
-	currencyIdx := p.findColumnIndex(header, "CURRENCY")
-	dateIdx := p.findColumnIndex(header, "TIME_PERIOD")
-	valueIdx := p.findColumnIndex(header, "OBS_VALUE")
+func (p *Provider) buildQuery(currencies []string, date time.Time) sdmx.Query {
+	// sorted so the same set of currencies is always the same request
+	currencies = slices.Sorted(slices.Values(currencies))
 
-	missingColumns := (currencyIdx == -1 || dateIdx == -1 || valueIdx == -1)
-	if missingColumns {
-		return nil, fmt.Errorf("missing required csv columns")
+	// EXR key: FREQ.CURRENCY.CURRENCY_DENOM.EXR_TYPE.EXR_SUFFIX, SP00.A is the daily reference rate
+	q := sdmx.Query{
+		Flow: "EXR",
+		Key:  [][]string{{"D"}, currencies, {"EUR"}, {"SP00"}, {"A"}},
 	}
 
+	hasSpecificDate := !date.IsZero()
+	if hasSpecificDate {
+		dateStr := date.Format("2006-01-02")
+		q.StartPeriod = dateStr
+		q.EndPeriod = dateStr
+	} else {
+		q.LastNObservations = 1
+	}
+
+	return q
+}
+
+// parseObservations maps data-api observations to rates, reporting the ones
+// that can't be, observations of other days are dropped without a report
+func (p *Provider) parseObservations(observations []sdmx.Observation, base string, requestDate time.Time) ([]models.Rate, common.ParseReport) {
 	var rates []models.Rate
+	var report common.ParseReport
 	now := time.Now()
 
-	for _, record := range records[1:] {
-		rate, skip := p.parseCSVRecord(record, currencyIdx, dateIdx, valueIdx, base, now, requestDate)
+	for i, obs := range observations {
+		rate, skip, err := p.parseObservation(obs, base, now, requestDate)
+		if err != nil {
+			report.Skip(fmt.Sprintf("observation %d", i+1), obs.SeriesKey["CURRENCY"], err.Error())
+			continue
+		}
 		if skip {
 			continue
 		}
 		rates = append(rates, rate)
 	}
 
-	return rates, nil
-}
-
-func (p *Provider) findColumnIndex(header []string, columnName string) int {
-	for i, col := range header {
-		if col == columnName {
-			return i
-		}
-	}
-	return -1
+	report.Parsed = len(rates)
+	return rates, report
 }
 
-func (p *Provider) parseCSVRecord(record []string, currencyIdx, dateIdx, valueIdx int, base string, fetchedAt time.Time, requestDate time.Time) (models.Rate, bool) {
-	insufficientColumns := (len(record) <= currencyIdx || len(record) <= dateIdx || len(record) <= valueIdx)
-	if insufficientColumns {
-		return models.Rate{}, true
+func (p *Provider) parseObservation(obs sdmx.Observation, base string, fetchedAt time.Time, requestDate time.Time) (models.Rate, bool, error) {
+	currency := obs.SeriesKey["CURRENCY"]
+	if currency == "" {
+		return models.Rate{}, true, fmt.Errorf("no CURRENCY dimension")
 	}
 
-	currency := record[currencyIdx]
-	dateStr := record[dateIdx]
-	valueStr := record[valueIdx]
-
-	date, err := time.Parse("2006-01-02", dateStr)
+	date, err := time.Parse("2006-01-02", obs.TimePeriod)
 	if err != nil {
-		return models.Rate{}, true
+		return models.Rate{}, true, fmt.Errorf("invalid period %q", obs.TimePeriod)
 	}
 
 	hasSpecificDate := !requestDate.IsZero()
 	wrongDate := hasSpecificDate && !date.Equal(requestDate.Truncate(24*time.Hour))
 	if wrongDate {
-		return models.Rate{}, true
+		return models.Rate{}, true, nil
 	}
 
-	value, err := strconv.ParseFloat(valueStr, 64)
-	if err != nil {
-		return models.Rate{}, true
+	value := obs.ScaledValue()
+	invalidValue := (value <= 0 || math.IsInf(value, 0))
+	if invalidValue {
+		return models.Rate{}, true, fmt.Errorf("invalid value %v", value)
 	}
 
 	return models.Rate{
@@ -180,17 +272,102 @@ func (p *Provider) parseCSVRecord(record []string, currencyIdx, dateIdx, valueId
 		Source:     "ECB",
 		Fetched:    fetchedAt,
 		Calculated: false,
-	}, false
+		Frequency:  models.FrequencyDaily,
+	}, false, nil
+}
+
+func filterTargets(rates []models.Rate, targets []string) []models.Rate {
+	wanted := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		wanted[target] = true
+	}
+
+	var filtered []models.Rate
+	for _, rate := range rates {
+		if wanted[rate.Target] {
+			filtered = append(filtered, rate)
+		}
+	}
+	return filtered
 }
 
-// TODO: a more dynamic approach could be implemented
 func (p *Provider) SupportedCurrencies() []string {
-	// actively updated currencies as of 2025
-	return []string{
-		"AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "CZK",
-		"DKK", "GBP", "HKD", "HUF", "IDR", "ILS", "INR",
-		"ISK", "JPY", "KRW", "MXN", "MYR", "NOK", "NZD",
-		"PHP", "PLN", "RON", "SEK", "SGD", "THB", "TRY",
-		"USD", "ZAR",
+	return p.currencies.List()
+}
+
+func (p *Provider) SetSupportedCurrencies(currencies []string) {
+	p.currencies.Set(currencies)
+}
+
+// DiscoverCurrencies reads the first and last observation of every daily EXR
+// reference series against EUR
+func (p *Provider) DiscoverCurrencies(ctx context.Context) ([]models.CurrencyInfo, error) {
+	q := sdmx.Query{
+		Flow:   "EXR",
+		Key:    [][]string{{"D"}, nil, {"EUR"}, {"SP00"}, {"A"}},
+		Detail: "dataonly",
 	}
+
+	first := q
+	first.FirstNObservations = 1
+	firstObs, firstReport, err := p.client.Data(ctx, first, sdmx.FormatCSV)
+	if err != nil {
+		return nil, fmt.Errorf("fetching first observations from ecb: %w", err)
+	}
+	firstReport.Warn(p.Name())
+
+	last := q
+	last.LastNObservations = 1
+	lastObs, lastReport, err := p.client.Data(ctx, last, sdmx.FormatCSV)
+	if err != nil {
+		return nil, fmt.Errorf("fetching last observations from ecb: %w", err)
+	}
+	lastReport.Warn(p.Name())
+
+	infos := make(map[string]*models.CurrencyInfo)
+	var order []string
+
+	record := func(obs sdmx.Observation, isFirst bool) {
+		currency := obs.SeriesKey["CURRENCY"]
+		date, err := time.Parse("2006-01-02", obs.TimePeriod)
+		if currency == "" || err != nil {
+			return
+		}
+
+		info, seen := infos[currency]
+		if !seen {
+			info = &models.CurrencyInfo{Source: "ECB", Currency: currency}
+			infos[currency] = info
+			order = append(order, currency)
+		}
+
+		if isFirst {
+			info.FirstDate = date
+		} else {
+			info.LastDate = date
+		}
+	}
+
+	for _, obs := range firstObs {
+		record(obs, true)
+	}
+	for _, obs := range lastObs {
+		record(obs, false)
+	}
+
+	var result []models.CurrencyInfo
+	for _, currency := range order {
+		result = append(result, *infos[currency])
+	}
+
+	return result, nil
+}
+
+// fallback until the live catalogue has been discovered, actively updated as of 2025
+var defaultCurrencies = []string{
+	"AUD", "BGN", "BRL", "CAD", "CHF", "CNY", "CZK",
+	"DKK", "GBP", "HKD", "HUF", "IDR", "ILS", "INR",
+	"ISK", "JPY", "KRW", "MXN", "MYR", "NOK", "NZD",
+	"PHP", "PLN", "RON", "SEK", "SGD", "THB", "TRY",
+	"USD", "ZAR",
 }
@@ -5,14 +5,16 @@ import (
 	"testing"
 
 	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/replay"
 )
 
+// responses are replayed from testdata, see the replay package to re-record them
 func TestFetchRates(t *testing.T) {
-	p := New()
+	p := New(WithHTTPClient(replay.HTTPClient("testdata")))
 	ctx := context.Background()
 
 	t.Run("direct EUR rates", func(t *testing.T) {
-		rates, err := p.FetchRates(ctx, models.RateRequest{
+		result, err := p.FetchRates(ctx, models.RateRequest{
 			Base:    "EUR",
 			Targets: []string{"USD", "GBP", "JPY"},
 		})
@@ -21,6 +23,7 @@ func TestFetchRates(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		rates := result.Rates
 		if len(rates) != 3 {
 			t.Fatalf("got %d rates, want 3", len(rates))
 		}
@@ -38,7 +41,7 @@ func TestFetchRates(t *testing.T) {
 	})
 
 	t.Run("cross rates via EUR", func(t *testing.T) {
-		rates, err := p.FetchRates(ctx, models.RateRequest{
+		result, err := p.FetchRates(ctx, models.RateRequest{
 			Base:    "USD",
 			Targets: []string{"JPY", "GBP"},
 		})
@@ -47,6 +50,7 @@ func TestFetchRates(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		rates := result.Rates
 		if len(rates) != 2 {
 			t.Fatalf("got %d rates, want 2", len(rates))
 		}
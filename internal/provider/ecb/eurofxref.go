@@ -0,0 +1,261 @@
+package ecb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// Feed selects where the ECB reference rates are read from
+type Feed string
+
+const (
+	// FeedDataAPI is the SDMX data-api, the default
+	FeedDataAPI Feed = "data-api"
+	// FeedDaily is eurofxref-daily.xml, only the latest business day
+	FeedDaily Feed = "daily"
+	// FeedHist90d is eurofxref-hist-90d.xml, the last 90 days
+	FeedHist90d Feed = "hist-90d"
+	// FeedHist is eurofxref-hist.zip, the full history since 1999 as CSV
+	FeedHist Feed = "hist"
+)
+
+var feedFiles = map[Feed]string{
+	FeedDaily:   "eurofxref-daily.xml",
+	FeedHist90d: "eurofxref-hist-90d.xml",
+	FeedHist:    "eurofxref-hist.zip",
+}
+
+// the 90-day file is published with some slack, so stay on the safe side
+const hist90dCoverage = 85 * 24 * time.Hour
+
+type envelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string `xml:"currency,attr"`
+			Rate     string `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// feedFor picks the smallest reference file that covers the requested date
+func feedFor(date time.Time) Feed {
+	isLatest := date.IsZero()
+	if isLatest {
+		return FeedDaily
+	}
+
+	isRecent := time.Since(date) < hist90dCoverage
+	if isRecent {
+		return FeedHist90d
+	}
+
+	return FeedHist
+}
+
+func (p *Provider) referenceURL(feed Feed) string {
+	return fmt.Sprintf("%s/%s", p.referenceBaseURL, feedFiles[feed])
+}
+
+func (p *Provider) parseFeed(feed Feed, data []byte) ([]models.Rate, common.ParseReport, error) {
+	isZip := (feed == FeedHist)
+	if isZip {
+		return p.parseHistZip(data)
+	}
+
+	return p.parseXML(data)
+}
+
+func (p *Provider) parseXML(data []byte) ([]models.Rate, common.ParseReport, error) {
+	var report common.ParseReport
+
+	var env envelope
+	if err := xml.Unmarshal(data, &env); err != nil {
+		return nil, report, fmt.Errorf("decoding xml: %w", err)
+	}
+
+	var rates []models.Rate
+	now := time.Now()
+
+	for d, day := range env.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			report.Skip(fmt.Sprintf("day %d", d+1), "", fmt.Sprintf("invalid time %q", day.Time))
+			continue
+		}
+
+		for _, r := range day.Rates {
+			rate, skip, err := p.newReferenceRate(r.Currency, r.Rate, date, now)
+			if err != nil {
+				report.Skip(day.Time, r.Currency, err.Error())
+				continue
+			}
+			if skip {
+				continue
+			}
+			rates = append(rates, rate)
+		}
+	}
+
+	noData := (len(rates) == 0)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	report.Parsed = len(rates)
+	return rates, report, nil
+}
+
+// parseHistZip reads the single CSV inside eurofxref-hist.zip:
+// a Date column followed by one column per currency, N/A where not quoted.
+// The CSV is held to the client's response size once decompressed.
+func (p *Provider) parseHistZip(data []byte) ([]models.Rate, common.ParseReport, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("opening zip: %w", err)
+	}
+
+	for _, file := range archive.File {
+		isCSV := strings.HasSuffix(strings.ToLower(file.Name), ".csv")
+		if !isCSV {
+			continue
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			return nil, common.ParseReport{}, fmt.Errorf("opening %s: %w", file.Name, err)
+		}
+		defer f.Close()
+
+		limit := p.http.MaxResponseSize()
+		csvData, err := io.ReadAll(io.LimitReader(f, limit+1))
+		if err != nil {
+			return nil, common.ParseReport{}, fmt.Errorf("reading %s: %w", file.Name, err)
+		}
+
+		tooLarge := (int64(len(csvData)) > limit)
+		if tooLarge {
+			return nil, common.ParseReport{}, fmt.Errorf("%s exceeds %d bytes decompressed", file.Name, limit)
+		}
+
+		return p.parseHistCSV(bytes.NewReader(csvData))
+	}
+
+	return nil, common.ParseReport{}, fmt.Errorf("no csv in archive")
+}
+
+func (p *Provider) parseHistCSV(r io.Reader) ([]models.Rate, common.ParseReport, error) {
+	var report common.ParseReport
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, report, fmt.Errorf("reading csv: %w", err)
+	}
+
+	noData := (len(records) < 2)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	header := records[0]
+	var rates []models.Rate
+	now := time.Now()
+
+	for n, record := range records[1:] {
+		row := fmt.Sprintf("row %d", n+1)
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			report.Skip(row, "", fmt.Sprintf("invalid date %q", record[0]))
+			continue
+		}
+
+		for i := 1; i < len(record) && i < len(header); i++ {
+			currency := strings.TrimSpace(header[i])
+			rate, skip, err := p.newReferenceRate(currency, record[i], date, now)
+			if err != nil {
+				report.Skip(row, currency, err.Error())
+				continue
+			}
+			if skip {
+				continue
+			}
+			rates = append(rates, rate)
+		}
+	}
+
+	report.Parsed = len(rates)
+	return rates, report, nil
+}
+
+// newReferenceRate skips blank columns and N/A quietly, anything else that
+// isn't a positive number is malformed
+func (p *Provider) newReferenceRate(currency, valueStr string, date time.Time, fetchedAt time.Time) (models.Rate, bool, error) {
+	if currency == "" {
+		return models.Rate{}, true, nil
+	}
+
+	trimmed := strings.TrimSpace(valueStr)
+	notQuoted := (trimmed == "" || trimmed == "N/A")
+	if notQuoted {
+		return models.Rate{}, true, nil
+	}
+
+	value, err := strconv.ParseFloat(trimmed, 64)
+	invalidValue := (err != nil || value <= 0 || math.IsInf(value, 0) || math.IsNaN(value))
+	if invalidValue {
+		return models.Rate{}, true, fmt.Errorf("invalid value %q", trimmed)
+	}
+
+	return models.Rate{
+		Base:       "EUR",
+		Target:     currency,
+		Value:      value,
+		Date:       date,
+		Source:     "ECB",
+		Fetched:    fetchedAt,
+		Calculated: false,
+		Frequency:  models.FrequencyDaily,
+	}, false, nil
+}
+
+// selectDate keeps the rates of the requested day, or of the latest day in the feed
+func selectDate(rates []models.Rate, date time.Time) []models.Rate {
+	target := date
+	isLatest := date.IsZero()
+	if isLatest {
+		for _, rate := range rates {
+			if rate.Date.After(target) {
+				target = rate.Date
+			}
+		}
+	}
+
+	var selected []models.Rate
+	for _, rate := range rates {
+		if isSameDay(rate.Date, target) {
+			selected = append(selected, rate)
+		}
+	}
+	return selected
+}
+
+func isSameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
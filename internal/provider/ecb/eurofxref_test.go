@@ -0,0 +1,158 @@
+package ecb
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+const sampleHist90d = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2025-10-17">
+			<Cube currency="USD" rate="1.1681"/>
+			<Cube currency="JPY" rate="175.48"/>
+		</Cube>
+		<Cube time="2025-10-16">
+			<Cube currency="USD" rate="1.1702"/>
+			<Cube currency="JPY" rate="176.01"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+const sampleHistCSV = "Date,USD,JPY,CYP,\n" +
+	"2025-10-17,1.1681,175.48,N/A,\n" +
+	"1999-01-04,1.1789,133.73,0.58231,\n"
+
+func TestParseXML(t *testing.T) {
+	p := New()
+
+	rates, report, err := p.parseFeed(FeedHist90d, []byte(sampleHist90d))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.Clean() {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	if len(rates) != 4 {
+		t.Fatalf("got %d rates, want 4", len(rates))
+	}
+
+	latest := selectDate(rates, time.Time{})
+	if len(latest) != 2 || latest[0].Date.Day() != 17 {
+		t.Errorf("latest day not selected: %+v", latest)
+	}
+
+	specific := filterTargets(selectDate(rates, time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)), []string{"JPY"})
+	if len(specific) != 1 || specific[0].Value != 176.01 {
+		t.Errorf("specific day not selected: %+v", specific)
+	}
+}
+
+func histZip(t *testing.T, csv string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("eurofxref-hist.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(csv))
+	w.Close()
+
+	return buf.Bytes()
+}
+
+func TestParseHistZip(t *testing.T) {
+	p := New()
+
+	rates, report, err := p.parseFeed(FeedHist, histZip(t, sampleHistCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// N/A and the trailing empty column are skipped, but not malformed
+	if len(rates) != 5 {
+		t.Fatalf("got %d rates, want 5", len(rates))
+	}
+
+	if !report.Clean() || report.Parsed != 5 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	for _, r := range rates {
+		correctBase := r.Base == "EUR"
+		positiveValue := r.Value > 0
+		if !correctBase || !positiveValue {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+}
+
+func TestParseHistZipLimit(t *testing.T) {
+	// the archive fits in 1KB, the CSV inside it is three times that
+	p := New(WithHTTPClient(common.NewHTTPClient(time.Second, common.WithMaxResponseSize(1<<10))))
+	csv := sampleHistCSV + strings.Repeat("2025-10-16,1.1702,176.01,N/A,\n", 100)
+
+	_, _, err := p.parseFeed(FeedHist, histZip(t, csv))
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected the decompressed size to be refused, got %v", err)
+	}
+
+	// the same archive fits a client that allows it
+	p = New(WithHTTPClient(common.NewHTTPClient(time.Second, common.WithMaxResponseSize(1<<20))))
+	if _, _, err := p.parseFeed(FeedHist, histZip(t, csv)); err != nil {
+		t.Errorf("unexpected error under the limit: %v", err)
+	}
+}
+
+func TestParseHistCSVReport(t *testing.T) {
+	p := New()
+
+	sample := "Date,USD,JPY,\n" +
+		"2025-10-17,1.1681,175.48,\n" +
+		"2025-10-16,1.17O2,176.01,\n" +
+		"16/10/2025,1.1702,176.01,\n"
+
+	rates, report, err := p.parseHistCSV(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 3 || report.Parsed != 3 || len(report.Skipped) != 2 {
+		t.Fatalf("got %d rates and report %s", len(rates), report)
+	}
+
+	usd := report.Skipped[0]
+	correctRecord := usd.Record == "row 2" && usd.Currency == "USD" && usd.Reason == `invalid value "1.17O2"`
+	if !correctRecord {
+		t.Errorf("invalid skipped record: %+v", usd)
+	}
+
+	correctDateRecord := report.Skipped[1].Record == "row 3" && report.Skipped[1].Currency == ""
+	if !correctDateRecord {
+		t.Errorf("invalid skipped record: %+v", report.Skipped[1])
+	}
+}
+
+func TestFeedFor(t *testing.T) {
+	cases := map[Feed]time.Time{
+		FeedDaily:   {},
+		FeedHist90d: time.Now().AddDate(0, 0, -10),
+		FeedHist:    time.Date(2010, 1, 4, 0, 0, 0, 0, time.UTC),
+	}
+
+	for want, date := range cases {
+		if got := feedFor(date); got != want {
+			t.Errorf("feedFor(%s) = %s, want %s", date, got, want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package ecb
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/providertest"
+	"github.com/xhos/fxgo/internal/provider/sdmx"
+)
+
+const sampleDataAPI = csvHeader +
+	"EXR.D.USD.EUR.SP00.A,D,USD,EUR,SP00,A,2025-10-17,1.1681,A,0\n" +
+	"EXR.D.JPY.EUR.SP00.A,D,JPY,EUR,SP00,A,2025-10-17,175.39,A,0\n" +
+	"EXR.D.RUB.EUR.SP00.A,D,RUB,EUR,SP00,A,2025-10-17,,,0\n"
+
+func FuzzParseObservations(f *testing.F) {
+	p := New()
+	f.Add([]byte(sampleDataAPI), int64(0))
+	f.Add([]byte(sampleDataAPI), time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC).Unix())
+	f.Add([]byte(csvHeader+"EXR.D.USD.EUR.SP00.A,D,USD,EUR,SP00,A,2025-10-17,1.1681,A,400\n"), int64(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, requestUnix int64) {
+		observations, decoded, err := sdmx.ParseCSV(data)
+		if err != nil {
+			return
+		}
+
+		var requestDate time.Time
+		if requestUnix != 0 {
+			requestDate = time.Unix(requestUnix, 0).UTC()
+		}
+
+		rates, report := p.parseObservations(observations, "EUR", requestDate)
+		providertest.CheckParsed(t, rates, report)
+
+		combined := decoded.Chain(report)
+		if len(combined.Skipped) < len(decoded.Skipped) {
+			t.Errorf("chaining lost skipped records: %s", combined)
+		}
+	})
+}
+
+func FuzzParseXML(f *testing.F) {
+	p := New()
+	f.Add([]byte(sampleHist90d))
+	f.Add([]byte(`<Envelope><Cube><Cube time="2025-10-17"><Cube currency="USD" rate="-1"/></Cube></Cube></Envelope>`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rates, report, err := p.parseXML(data)
+		if err != nil {
+			return
+		}
+		providertest.CheckParsed(t, rates, report)
+	})
+}
+
+func FuzzParseHistCSV(f *testing.F) {
+	p := New()
+	f.Add([]byte(sampleHistCSV))
+	f.Add([]byte("Date,USD\n2025-10-17,1e999\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		rates, report, err := p.parseHistCSV(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		providertest.CheckParsed(t, rates, report)
+	})
+}
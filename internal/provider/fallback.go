@@ -0,0 +1,138 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+// Fallback queries providers in priority order, each target is served by the
+// first provider that supports it and manages to return it. Aggregators with
+// broad but lagging coverage (e.g. BIS) belong at the end of the list.
+type Fallback struct {
+	providers []Provider
+}
+
+func NewFallback(providers ...Provider) *Fallback {
+	return &Fallback{providers: providers}
+}
+
+// FetchRates explains a missing target by the most telling answer any provider
+// gave for it, targets no provider was asked for are unsupported
+func (f *Fallback) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	remaining := slices.Clone(req.Targets)
+	outcomes := make(map[string]models.TargetResult)
+
+	var rates []models.Rate
+	var errs []error
+
+	for _, p := range f.providers {
+		noneLeft := (len(remaining) == 0)
+		if noneLeft {
+			break
+		}
+
+		if !supports(p, req.Base) {
+			continue
+		}
+
+		var targets []string
+		for _, target := range remaining {
+			if supports(p, target) {
+				targets = append(targets, target)
+			}
+		}
+
+		nothingToAsk := (len(targets) == 0)
+		if nothingToAsk {
+			continue
+		}
+
+		// IfModified isn't passed on, each provider is asked a different subset of the targets
+		fetched, err := p.FetchRates(ctx, models.RateRequest{
+			Base:    req.Base,
+			Targets: targets,
+			Date:    req.Date,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			for _, target := range targets {
+				record(outcomes, p, models.TargetResult{Currency: target, Status: models.StatusFailed, Detail: err.Error()})
+			}
+			continue
+		}
+
+		for _, outcome := range fetched.Targets {
+			record(outcomes, p, outcome)
+		}
+
+		for _, rate := range fetched.Rates {
+			remaining = slices.DeleteFunc(remaining, func(target string) bool {
+				return target == rate.Target
+			})
+		}
+		rates = append(rates, fetched.Rates...)
+	}
+
+	noRates := (len(rates) == 0)
+	if noRates {
+		errs = append(errs, fmt.Errorf("no provider returned rates for %s", req.Base))
+		return models.RateResult{}, errors.Join(errs...)
+	}
+
+	result := models.RateResult{Rates: rates}
+	for _, target := range req.Targets {
+		outcome, asked := outcomes[target]
+		if !asked {
+			outcome = models.TargetResult{Currency: target, Status: models.StatusUnsupported}
+		}
+		result.Targets = append(result.Targets, outcome)
+	}
+
+	return result, nil
+}
+
+// statusRank orders outcomes by how much they tell, a parse error points at a
+// format change while a failed request says nothing about the currency
+var statusRank = map[string]int{
+	models.StatusReturned:     4,
+	models.StatusParseError:   3,
+	models.StatusNotPublished: 2,
+	models.StatusFailed:       1,
+	models.StatusUnsupported:  0,
+}
+
+// record keeps the most telling outcome per target, details name the provider
+func record(outcomes map[string]models.TargetResult, p Provider, outcome models.TargetResult) {
+	if outcome.Detail != "" {
+		outcome.Detail = p.Name() + ": " + outcome.Detail
+	}
+
+	current, seen := outcomes[outcome.Currency]
+	isBetter := !seen || statusRank[outcome.Status] > statusRank[current.Status]
+	if isBetter {
+		outcomes[outcome.Currency] = outcome
+	}
+}
+
+// SupportedCurrencies is the union of every provider's currencies, bases included
+func (f *Fallback) SupportedCurrencies() []string {
+	var currencies []string
+	for _, p := range f.providers {
+		currencies = append(currencies, p.Base())
+		currencies = append(currencies, p.SupportedCurrencies()...)
+	}
+
+	slices.Sort(currencies)
+	return slices.Compact(currencies)
+}
+
+// supports counts a provider's base for targets too, cross requests return
+// it as the inverse of the requested base
+func supports(p Provider, currency string) bool {
+	isBase := (p.Base() == currency)
+	return isBase || slices.Contains(p.SupportedCurrencies(), currency)
+}
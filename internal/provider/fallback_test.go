@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+type stubProvider struct {
+	name       string
+	base       string
+	currencies []string
+	err        error
+	// withheld currencies are supported but not published
+	withheld []string
+}
+
+func (s stubProvider) Name() string                  { return s.name }
+func (s stubProvider) Base() string                  { return s.base }
+func (s stubProvider) SupportedCurrencies() []string { return s.currencies }
+
+func (s stubProvider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	if s.err != nil {
+		return models.RateResult{}, s.err
+	}
+
+	result := models.RateResult{}
+	for _, target := range req.Targets {
+		withheld := slices.Contains(s.withheld, target)
+		if withheld {
+			result.Targets = append(result.Targets, models.TargetResult{Currency: target, Status: models.StatusNotPublished})
+			continue
+		}
+
+		result.Rates = append(result.Rates, models.Rate{Base: req.Base, Target: target, Value: 1, Date: time.Now(), Source: s.name})
+		result.Targets = append(result.Targets, models.TargetResult{Currency: target, Status: models.StatusReturned})
+	}
+	return result, nil
+}
+
+func TestFallback(t *testing.T) {
+	central := stubProvider{name: "central", base: "USD", currencies: []string{"EUR", "JPY"}}
+	broken := stubProvider{name: "broken", base: "USD", currencies: []string{"AFN"}, err: errors.New("down")}
+	aggregator := stubProvider{name: "aggregator", base: "USD", currencies: []string{"EUR", "AFN", "XAF", "MRU"}, withheld: []string{"MRU"}}
+
+	f := NewFallback(central, broken, aggregator)
+
+	result, err := f.FetchRates(context.Background(), models.RateRequest{
+		Base:    "USD",
+		Targets: []string{"EUR", "AFN", "XAF", "MRU", "ZZZ"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rates := result.Rates
+
+	want := map[string]string{"EUR": "central", "AFN": "aggregator", "XAF": "aggregator"}
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d", len(rates), len(want))
+	}
+
+	for _, r := range rates {
+		if r.Source != want[r.Target] {
+			t.Errorf("%s served by %s, want %s", r.Target, r.Source, want[r.Target])
+		}
+	}
+
+	wantStatus := map[string]string{
+		"EUR": models.StatusReturned,
+		"AFN": models.StatusReturned,
+		"XAF": models.StatusReturned,
+		"MRU": models.StatusNotPublished,
+		"ZZZ": models.StatusUnsupported,
+	}
+	for currency, status := range wantStatus {
+		if got := result.Status(currency); got != status {
+			t.Errorf("%s has status %q, want %q", currency, got, status)
+		}
+	}
+
+	// a failed provider is reported when nobody else had the currency
+	partial, err := NewFallback(central, broken).FetchRates(context.Background(), models.RateRequest{Base: "USD", Targets: []string{"EUR", "AFN"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	afn := partial.Targets[1]
+	correctOutcome := afn.Status == models.StatusFailed && afn.Detail == "broken: down"
+	if !correctOutcome {
+		t.Errorf("unexpected outcome for AFN: %s", afn)
+	}
+
+	_, err = NewFallback(broken).FetchRates(context.Background(), models.RateRequest{Base: "USD", Targets: []string{"AFN"}})
+	if err == nil {
+		t.Error("expected error when every provider fails, got none")
+	}
+}
+
+func TestFallbackBaseAsTarget(t *testing.T) {
+	// USD to EUR is a cross request for a EUR-based provider, EUR comes back as 1/EURUSD
+	central := stubProvider{name: "central", base: "EUR", currencies: []string{"USD", "PLN"}}
+	aggregator := stubProvider{name: "aggregator", base: "USD", currencies: []string{"PLN"}}
+
+	f := NewFallback(aggregator, central)
+
+	result, err := f.FetchRates(context.Background(), models.RateRequest{Base: "USD", Targets: []string{"EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correct := len(result.Rates) == 1 && result.Rates[0].Source == "central" && result.Targets[0].Status == models.StatusReturned
+	if !correct {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
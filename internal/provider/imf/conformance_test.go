@@ -0,0 +1,89 @@
+package imf
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+func TestConformance(t *testing.T) {
+	newProvider := func(baseURL string, client *common.HTTPClient) provider.Provider {
+		return New(WithBaseURL(baseURL), WithHTTPClient(client))
+	}
+
+	providertest.RunWith(t, newProvider, fakeRMS, []byte("Currency\tOctober 17, 2025\n"),
+		[]byte("Currency\tOctober 17, 2025\nU.K. pound\tn/a\n"),
+		[]byte("Currency\t17/10/2025\nU.K. pound\t1.3400\n"),
+		[]byte("Currency\tOctober 17, 2025\nU.K. pound\t-1.3400\nSDR1 = US$\t\t\t1.368120\n"),
+	)
+}
+
+// fakeRMS serves the tsv reports like imf.org: the representative rates for
+// the month of SelectDate, with a column per day that has data, and the SDR
+// valuation of the fixture's day. Nothing is published after the fixture, so
+// later dates get its month.
+func fakeRMS(f providertest.Fixture) http.Handler {
+	names := make(map[string]string, len(currencyCodes))
+	for name, code := range currencyCodes {
+		names[code] = name
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isTSV := (r.URL.Query().Get("tsvflag") == "Y")
+		if !isTSV {
+			http.NotFound(w, r)
+			return
+		}
+
+		day := f.Date.Format("January 2, 2006")
+		w.Header().Set("Content-Type", "text/tab-separated-values")
+
+		switch r.URL.Path {
+		case "/rms_mth.aspx":
+			selected, err := time.Parse("2006-01-02", r.URL.Query().Get("SelectDate"))
+			if err != nil {
+				http.Error(w, "invalid SelectDate", http.StatusBadRequest)
+				return
+			}
+
+			if selected.After(f.Date) {
+				selected = f.Date
+			}
+
+			fmt.Fprintf(w, "Representative Exchange Rates for Selected Currencies for %s\n\n", selected.Format("January 2006"))
+			sameMonth := (selected.Year() == f.Date.Year() && selected.Month() == f.Date.Month())
+			if !sameMonth {
+				fmt.Fprint(w, "Currency\n")
+				return
+			}
+
+			var currencies []string
+			for currency := range f.Rates {
+				currencies = append(currencies, currency)
+			}
+			slices.Sort(currencies)
+
+			fmt.Fprintf(w, "Currency\t%s\n", day)
+			for _, currency := range currencies {
+				value := f.Rates[currency]
+				if usdPerUnit[currency] {
+					value = 1 / value
+				}
+				fmt.Fprintf(w, "%s\t%s\n", names[currency], strconv.FormatFloat(value, 'f', -1, 64))
+			}
+		case "/rms_sdrv.aspx":
+			fmt.Fprintf(w, "SDR Valuation\n%s\n", day)
+			fmt.Fprint(w, "Currency\tCurrency amount under Rule O-1\tExchange rate\tU.S. dollar equivalent\n")
+			fmt.Fprint(w, "SDR1 = US$\t\t\t1.368120\n")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
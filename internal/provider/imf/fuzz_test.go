@@ -0,0 +1,29 @@
+package imf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+func FuzzParseRepresentative(f *testing.F) {
+	p := New()
+	f.Add([]byte(sampleRepresentative), int64(0))
+	f.Add([]byte(sampleRepresentative), time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC).Unix())
+	f.Add([]byte("Currency\tOctober 1, 2025\tx\nEuro\t4.9e-324\t1\nU.S. dollar\tNaN\nAustralian dollar (AUD)\t1e400\n"), int64(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, requestUnix int64) {
+		var requestDate time.Time
+		if requestUnix != 0 {
+			requestDate = time.Unix(requestUnix, 0).UTC()
+		}
+
+		rates, report, err := p.parseRepresentative(data, requestDate, time.Now())
+		if err != nil {
+			return
+		}
+
+		providertest.CheckParsed(t, rates, report)
+	})
+}
@@ -0,0 +1,462 @@
+package imf
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+type Provider struct {
+	baseURL string
+	client  *common.HTTPClient
+}
+
+// SDR is the ISO 4217 code of the IMF special drawing right
+const SDR = "XDR"
+
+var (
+	codePattern = regexp.MustCompile(`\(([A-Z]{3})\)`)
+	datePattern = regexp.MustCompile(`[A-Z][a-z]+ \d{1,2}, \d{4}`)
+)
+
+// currency names used in the representative rates table
+var currencyCodes = map[string]string{
+	"algerian dinar":      "DZD",
+	"australian dollar":   "AUD",
+	"botswana pula":       "BWP",
+	"brazilian real":      "BRL",
+	"brunei dollar":       "BND",
+	"canadian dollar":     "CAD",
+	"chilean peso":        "CLP",
+	"chinese yuan":        "CNY",
+	"czech koruna":        "CZK",
+	"danish krone":        "DKK",
+	"euro":                "EUR",
+	"indian rupee":        "INR",
+	"israeli new shekel":  "ILS",
+	"japanese yen":        "JPY",
+	"korean won":          "KRW",
+	"kuwaiti dinar":       "KWD",
+	"malaysian ringgit":   "MYR",
+	"mauritian rupee":     "MUR",
+	"mexican peso":        "MXN",
+	"new zealand dollar":  "NZD",
+	"norwegian krone":     "NOK",
+	"omani rial":          "OMR",
+	"peruvian sol":        "PEN",
+	"philippine peso":     "PHP",
+	"polish zloty":        "PLN",
+	"qatari riyal":        "QAR",
+	"russian ruble":       "RUB",
+	"saudi arabian riyal": "SAR",
+	"singapore dollar":    "SGD",
+	"south african rand":  "ZAR",
+	"swedish krona":       "SEK",
+	"swiss franc":         "CHF",
+	"thai baht":           "THB",
+	"trinidadian dollar":  "TTD",
+	"u.a.e. dirham":       "AED",
+	"u.k. pound":          "GBP",
+	"u.s. dollar":         "USD",
+	"uruguayan peso":      "UYU",
+	"bahrain dinar":       "BHD",
+	"iranian rial":        "IRR",
+	"colombian peso":      "COP",
+	"hungarian forint":    "HUF",
+	"indonesian rupiah":   "IDR",
+	"kazakhstani tenge":   "KZT",
+	"nepalese rupee":      "NPR",
+	"pakistani rupee":     "PKR",
+	"sri lankan rupee":    "LKR",
+	"tunisian dinar":      "TND",
+	"libyan dinar":        "LYD",
+	"iceland krona":       "ISK",
+	"bangladeshi taka":    "BDT",
+}
+
+// quoted as U.S. dollars per currency unit in the representative rates table,
+// every other currency is quoted as currency units per U.S. dollar
+var usdPerUnit = map[string]bool{
+	"AUD": true,
+	"EUR": true,
+	"GBP": true,
+	"NZD": true,
+}
+
+type table struct {
+	dates []time.Time
+	// column of each date, a header cell that isn't a date has no column
+	cols []int
+	rows map[string][]float64
+}
+
+type Option func(*Provider)
+
+// WithBaseURL points the provider at another copy of the rate tables, e.g. a test server
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces the default client, e.g. to add a cache or replay fixtures
+func WithHTTPClient(client *common.HTTPClient) Option {
+	return func(p *Provider) {
+		p.client = client
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		baseURL: "https://www.imf.org/external/np/fin/data",
+		client:  common.NewHTTPClient(30 * time.Second),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *Provider) Name() string {
+	return "IMF"
+}
+
+func (p *Provider) Base() string {
+	return "USD"
+}
+
+func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	isDirectUSD := (req.Base == "USD")
+	if isDirectUSD {
+		return p.fetchDirectRates(ctx, req)
+	}
+
+	return p.fetchCrossRates(ctx, req)
+}
+
+func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	usdRates, report, err := p.fetchUSDRates(ctx, req.Date)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	rates := filterTargets(usdRates, req.Targets)
+	if err := common.ValidateRates(rates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	// both tables are fetched whole, so the base is already in them
+	usdRates, report, err := p.fetchUSDRates(ctx, req.Date)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	if err := common.ValidateRates(usdRates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating usd rates: %w", err)
+	}
+
+	rates, err := common.CalculateCrossRates(usdRates, req.Base, req.Targets)
+	if err != nil {
+		return models.RateResult{}, fmt.Errorf("calculating cross rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+// fetchUSDRates combines the representative rates with the SDR valuation
+func (p *Provider) fetchUSDRates(ctx context.Context, date time.Time) ([]models.Rate, common.ParseReport, error) {
+	now := time.Now()
+
+	repBody, err := p.client.Get(ctx, p.buildRepresentativeURL(date))
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching representative rates from imf: %w", err)
+	}
+
+	rates, report, err := p.parseRepresentative(repBody, date, now)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("parsing representative rates: %w", err)
+	}
+	report.Warn(p.Name())
+
+	sdrBody, err := p.client.Get(ctx, p.buildValuationURL(date))
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching sdr valuation from imf: %w", err)
+	}
+
+	sdrRate, err := p.parseValuation(sdrBody, date, now)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("parsing sdr valuation: %w", err)
+	}
+
+	return append(rates, sdrRate), report, nil
+}
+
+func (p *Provider) buildRepresentativeURL(date time.Time) string {
+	selectDate := date
+	if selectDate.IsZero() {
+		selectDate = time.Now()
+	}
+
+	return fmt.Sprintf("%s/rms_mth.aspx?SelectDate=%s&reportType=REP&tsvflag=Y", p.baseURL, selectDate.Format("2006-01-02"))
+}
+
+func (p *Provider) buildValuationURL(date time.Time) string {
+	url := p.baseURL + "/rms_sdrv.aspx?tsvflag=Y"
+
+	hasSpecificDate := !date.IsZero()
+	if hasSpecificDate {
+		url += "&SelectDate=" + date.Format("2006-01-02")
+	}
+
+	return url
+}
+
+// parseRepresentative reads the month table of representative rates:
+// one row per currency, one column per day, blanks where nothing was reported
+func (p *Provider) parseRepresentative(data []byte, requestDate time.Time, fetchedAt time.Time) ([]models.Rate, common.ParseReport, error) {
+	t, report, err := p.parseTable(data)
+	if err != nil {
+		return nil, report, err
+	}
+
+	var rates []models.Rate
+	for currency, values := range t.rows {
+		isUSD := (currency == "USD")
+		if isUSD {
+			continue
+		}
+
+		idx := pickColumn(t.dates, values, requestDate)
+		if idx == -1 {
+			continue
+		}
+
+		value := values[idx]
+		if usdPerUnit[currency] {
+			value = 1 / value
+		}
+
+		rates = append(rates, models.Rate{
+			Base:       "USD",
+			Target:     currency,
+			Value:      value,
+			Date:       t.dates[idx],
+			Source:     "IMF",
+			Fetched:    fetchedAt,
+			Calculated: false,
+			Frequency:  models.FrequencyDaily,
+		})
+	}
+
+	noData := (len(rates) == 0)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	slices.SortFunc(rates, func(a, b models.Rate) int {
+		return strings.Compare(a.Target, b.Target)
+	})
+
+	report.Parsed = len(rates)
+	return rates, report, nil
+}
+
+// parseTable reads the currency rows under the date header, blank cells are
+// days without a rate and anything else that isn't a positive number is malformed
+func (p *Provider) parseTable(data []byte) (table, common.ParseReport, error) {
+	t := table{rows: make(map[string][]float64)}
+	var report common.ParseReport
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+
+		isHeader := (len(t.dates) == 0 && len(fields) > 1 && strings.EqualFold(strings.TrimSpace(fields[0]), "currency"))
+		if isHeader {
+			for col := 1; col < len(fields); col++ {
+				date, err := time.Parse("January 2, 2006", strings.TrimSpace(fields[col]))
+				if err != nil {
+					continue
+				}
+				t.dates = append(t.dates, date)
+				t.cols = append(t.cols, col)
+			}
+			continue
+		}
+
+		headerSeen := (len(t.dates) > 0)
+		if !headerSeen {
+			continue
+		}
+
+		currency := currencyCode(fields[0])
+		if currency == "" {
+			continue
+		}
+
+		values := make([]float64, len(t.dates))
+		for i, col := range t.cols {
+			if col >= len(fields) {
+				break
+			}
+			cell := strings.TrimSpace(fields[col])
+			if cell == "" {
+				continue
+			}
+
+			// quoted either way round, so the inverse has to be usable too
+			value, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", ""), 64)
+			invalidValue := (err != nil || !(value > 0) || math.IsInf(value, 0) || math.IsInf(1/value, 0))
+			if invalidValue {
+				report.Skip(fmt.Sprintf("line %d", line), currency, fmt.Sprintf("invalid value %q for %s", cell, t.dates[i].Format("2006-01-02")))
+				continue
+			}
+			values[i] = value
+		}
+		t.rows[currency] = values
+	}
+
+	if err := scanner.Err(); err != nil {
+		return table{}, report, fmt.Errorf("reading table: %w", err)
+	}
+
+	missingHeader := (len(t.dates) == 0)
+	if missingHeader {
+		return table{}, report, fmt.Errorf("missing date header")
+	}
+
+	return t, report, nil
+}
+
+// parseValuation reads the SDR valuation basket and turns its "SDR1 = US$" line into USD/XDR
+func (p *Provider) parseValuation(data []byte, requestDate time.Time, fetchedAt time.Time) (models.Rate, error) {
+	page := string(data)
+
+	dateStr := datePattern.FindString(page)
+	date, err := time.Parse("January 2, 2006", dateStr)
+	if err != nil {
+		return models.Rate{}, fmt.Errorf("valuation date not found")
+	}
+
+	hasSpecificDate := !requestDate.IsZero()
+	wrongDate := hasSpecificDate && !isSameDay(date, requestDate)
+	if wrongDate {
+		return models.Rate{}, fmt.Errorf("valuation is for %s, requested %s", date.Format("2006-01-02"), requestDate.Format("2006-01-02"))
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(page))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+
+		isSDRLine := strings.HasPrefix(strings.ReplaceAll(fields[0], " ", ""), "SDR1=US$")
+		if !isSDRLine {
+			continue
+		}
+
+		usdPerSDR, ok := lastNumber(fields[1:])
+		if !ok {
+			return models.Rate{}, fmt.Errorf("invalid sdr value")
+		}
+
+		return models.Rate{
+			Base:       "USD",
+			Target:     SDR,
+			Value:      1 / usdPerSDR,
+			Date:       date,
+			Source:     "IMF",
+			Fetched:    fetchedAt,
+			Calculated: false,
+			Frequency:  models.FrequencyDaily,
+		}, nil
+	}
+
+	return models.Rate{}, fmt.Errorf("sdr value not found")
+}
+
+// currencyCode resolves a row label like "Euro" or "Chinese yuan (CNY)"
+func currencyCode(label string) string {
+	if match := codePattern.FindStringSubmatch(label); match != nil {
+		return match[1]
+	}
+
+	name := strings.ToLower(strings.TrimSpace(strings.TrimRight(label, "* ")))
+	return currencyCodes[name]
+}
+
+// pickColumn returns the column for the requested day, or the latest reported one
+func pickColumn(dates []time.Time, values []float64, requestDate time.Time) int {
+	hasSpecificDate := !requestDate.IsZero()
+	if hasSpecificDate {
+		for i, date := range dates {
+			if isSameDay(date, requestDate) && values[i] > 0 {
+				return i
+			}
+		}
+		return -1
+	}
+
+	latest := -1
+	for i, date := range dates {
+		isNewer := (latest == -1 || date.After(dates[latest]))
+		if values[i] > 0 && isNewer {
+			latest = i
+		}
+	}
+	return latest
+}
+
+func lastNumber(fields []string) (float64, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[i]), 64)
+		if err == nil && value > 0 {
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+func filterTargets(rates []models.Rate, targets []string) []models.Rate {
+	wanted := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		wanted[target] = true
+	}
+
+	var filtered []models.Rate
+	for _, rate := range rates {
+		if wanted[rate.Target] {
+			filtered = append(filtered, rate)
+		}
+	}
+	return filtered
+}
+
+func isSameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func (p *Provider) SupportedCurrencies() []string {
+	// every currency the representative rates table is read for, plus the SDR itself
+	currencies := []string{SDR}
+	for _, code := range currencyCodes {
+		currencies = append(currencies, code)
+	}
+	slices.Sort(currencies)
+	return slices.Compact(currencies)
+}
@@ -0,0 +1,168 @@
+package imf
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleRepresentative = "Representative Exchange Rates for Selected Currencies for October 2025\n" +
+	"\n" +
+	"Currency\tOctober 15, 2025\tOctober 16, 2025\tOctober 17, 2025\n" +
+	"Chinese yuan\t7.1290\t7.1270\t\n" +
+	"Euro\t1.1650\t1.1700\t1.1680\n" +
+	"Japanese yen\t151.0100\t150.3300\t150.6200\n" +
+	"U.S. dollar\t1.0000\t1.0000\t1.0000\n" +
+	"Unknown peso\t12.0\t12.0\t12.0\n"
+
+const sampleValuation = "SDR Valuation\n" +
+	"October 17, 2025\n" +
+	"Currency\tCurrency amount under Rule O-1\tExchange rate\tU.S. dollar equivalent\n" +
+	"Chinese yuan\t1.0993\t7.1270\t0.154245\n" +
+	"Euro\t0.37379\t1.1680\t0.436587\n" +
+	"SDR1 = US$\t\t\t1.368120\n" +
+	"US$1 = SDR\t\t\t0.730930\n"
+
+func TestParseRepresentative(t *testing.T) {
+	p := New()
+
+	rates, report, err := p.parseRepresentative([]byte(sampleRepresentative), time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// CNY's blank on the 17th is a day without a rate, not a malformed cell
+	if !report.Clean() || report.Parsed != 3 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	want := map[string]struct {
+		value float64
+		day   int
+	}{
+		"CNY": {7.1270, 16},
+		"EUR": {1 / 1.1680, 17},
+		"JPY": {150.62, 17},
+	}
+
+	if len(rates) != len(want) {
+		t.Fatalf("got %d rates, want %d", len(rates), len(want))
+	}
+
+	for _, r := range rates {
+		w := want[r.Target]
+		correctValue := math.Abs(r.Value-w.value) < 1e-9
+		correctDay := r.Date.Day() == w.day
+		correctBase := r.Base == "USD"
+
+		if !correctValue || !correctDay || !correctBase {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+
+	specific, _, err := p.parseRepresentative([]byte(sampleRepresentative), time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(specific) != 3 {
+		t.Errorf("got %d rates for specific date, want 3", len(specific))
+	}
+}
+
+func TestParseValuation(t *testing.T) {
+	p := New()
+
+	rate, err := p.parseValuation([]byte(sampleValuation), time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correctTarget := rate.Target == SDR
+	correctValue := math.Abs(rate.Value-1/1.368120) < 1e-12
+	correctDate := rate.Date.Equal(time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC))
+
+	if !correctTarget || !correctValue || !correctDate {
+		t.Errorf("invalid sdr rate: %+v", rate)
+	}
+
+	_, err = p.parseValuation([]byte(sampleValuation), time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC), time.Now())
+	if err == nil {
+		t.Error("expected error for mismatched date, got none")
+	}
+}
+
+func TestParseTableColumns(t *testing.T) {
+	p := New()
+
+	// single digit days aren't padded, and a cell that isn't a date mustn't shift the values after it
+	data := "Currency\tOctober 1, 2025\tNotes\tOctober 2, 2025\n" +
+		"Euro\t1.1600\tsee below\t1.1700\n" +
+		"Japanese yen\t148.5000\t\t149.2500\n"
+
+	tbl, _, err := p.parseTable([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correctDates := len(tbl.dates) == 2 && tbl.dates[0].Day() == 1 && tbl.dates[1].Day() == 2
+	if !correctDates {
+		t.Fatalf("unexpected dates: %v", tbl.dates)
+	}
+
+	want := map[string][]float64{
+		"EUR": {1.16, 1.17},
+		"JPY": {148.5, 149.25},
+	}
+	for currency, values := range want {
+		got := tbl.rows[currency]
+		correct := len(got) == 2 && got[0] == values[0] && got[1] == values[1]
+		if !correct {
+			t.Errorf("%s: got %v, want %v", currency, got, values)
+		}
+	}
+}
+
+func TestSupportedCurrencies(t *testing.T) {
+	p := New()
+	supported := p.SupportedCurrencies()
+
+	rates, _, err := p.parseRepresentative([]byte(sampleRepresentative), time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sdr, err := p.parseValuation([]byte(sampleValuation), time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every rate the tables yield is for a listed currency
+	for _, r := range append(rates, sdr) {
+		if !slices.Contains(supported, r.Target) {
+			t.Errorf("%s is parsed but not supported", r.Target)
+		}
+	}
+}
+
+func TestParseRepresentativeReport(t *testing.T) {
+	p := New()
+	data := strings.Replace(sampleRepresentative, "150.6200", "150.62OO", 1)
+
+	rates, report, err := p.parseRepresentative([]byte(data), time.Time{}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 3 || report.Parsed != 3 {
+		t.Errorf("got %d rates and %s, want 3", len(rates), report)
+	}
+
+	// JPY falls back to the 16th, the broken cell on the 17th is still reported
+	skippedJPY := slices.Equal(report.SkippedCurrencies(), []string{"JPY"})
+	if !skippedJPY {
+		t.Errorf("expected the malformed JPY cell to be reported: %s", report)
+	}
+}
@@ -0,0 +1,66 @@
+package mufg
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+const tableHeader = "<tr><th>通貨名</th><th>Currency</th><th>略号</th><th>T.T.S.</th><th>T.T.B.</th><th>T.T.M.</th></tr>\n"
+
+func TestConformance(t *testing.T) {
+	newProvider := func(baseURL string, client *common.HTTPClient) provider.Provider {
+		return New(WithBaseURL(baseURL), WithHTTPClient(client))
+	}
+
+	providertest.RunWith(t, newProvider, fakeTTM, []byte(page("2025年10月17日", "")),
+		[]byte(page("2025年10月17日", "<tr><td>米ドル</td><td>US Dollar</td><td>USD</td><td>-</td><td>-</td><td>-</td></tr>")),
+		[]byte(page("2025年10月17日", "<tr><td>米ドル</td><td>USD</td><td>151.14</td></tr>")),
+		[]byte(page("", "<tr><td>米ドル</td><td>US Dollar</td><td>USD</td><td>152.14</td><td>150.14</td><td>151.14</td></tr>")),
+	)
+}
+
+// fakeTTM serves the TTM table like murc-kawasesouba.jp: the latest table at
+// index.php, past ones by date and a 404 for days without a table
+func fakeTTM(f providertest.Fixture) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isLatest := (r.URL.Path == "/index.php")
+		isPublishedDay := (r.URL.Path == "/past/index.php" && r.URL.Query().Get("id") == f.Date.Format("060102"))
+		if !isLatest && !isPublishedDay {
+			http.NotFound(w, r)
+			return
+		}
+
+		var currencies []string
+		for currency := range f.Rates {
+			currencies = append(currencies, currency)
+		}
+		slices.Sort(currencies)
+
+		var rows strings.Builder
+		for _, currency := range currencies {
+			// the table quotes yen per unit of the foreign currency
+			ttm := 1 / f.Rates[currency]
+			fmt.Fprintf(&rows, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				currency, currency, currency, quote(ttm*1.01), quote(ttm*0.99), quote(ttm))
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		fmt.Fprint(w, page(f.Date.Format("2006年1月2日"), rows.String()))
+	})
+}
+
+func page(published, rows string) string {
+	return "<html><body><p>" + published + "</p><table>\n" + tableHeader + rows + "</table></body></html>"
+}
+
+func quote(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,29 @@
+package mufg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/providertest"
+)
+
+func FuzzParseTable(f *testing.F) {
+	p := New()
+	f.Add([]byte(samplePage), int64(0))
+	f.Add([]byte(samplePage), time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC).Unix())
+	f.Add([]byte("2025年10月17日<tr><td>a</td><td>b</td><td>KRW</td><td></td><td></td><td>4.9e-324</td></tr><tr><td>USD</td></tr>"), int64(0))
+
+	f.Fuzz(func(t *testing.T, data []byte, requestUnix int64) {
+		var requestDate time.Time
+		if requestUnix != 0 {
+			requestDate = time.Unix(requestUnix, 0).UTC()
+		}
+
+		rates, report, err := p.parseTable(data, requestDate)
+		if err != nil {
+			return
+		}
+
+		providertest.CheckParsed(t, rates, report)
+	})
+}
@@ -0,0 +1,288 @@
+package mufg
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/calendar"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+type Provider struct {
+	baseURL string
+	client  *common.HTTPClient
+}
+
+var (
+	rowPattern  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	cellPattern = regexp.MustCompile(`(?is)<t[dh][^>]*>(.*?)</t[dh]>`)
+	tagPattern  = regexp.MustCompile(`(?s)<[^>]*>`)
+	datePattern = regexp.MustCompile(`(\d{4})年\s*(\d{1,2})月\s*(\d{1,2})日`)
+	codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// quoted per 100 units of the foreign currency instead of per 1
+var perHundred = map[string]bool{
+	"IDR": true,
+	"KRW": true,
+}
+
+type Option func(*Provider)
+
+// WithBaseURL points the provider at another copy of the rate pages, e.g. a test server
+func WithBaseURL(baseURL string) Option {
+	return func(p *Provider) {
+		p.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces the default client, e.g. to add a cache or replay fixtures
+func WithHTTPClient(client *common.HTTPClient) Option {
+	return func(p *Provider) {
+		p.client = client
+	}
+}
+
+func New(opts ...Option) *Provider {
+	p := &Provider{
+		baseURL: "https://www.murc-kawasesouba.jp/fx",
+		client:  common.NewHTTPClient(30 * time.Second),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+func (p *Provider) Name() string {
+	return "MUFG"
+}
+
+func (p *Provider) Base() string {
+	return "JPY"
+}
+
+func (p *Provider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	isDirectJPY := (req.Base == "JPY")
+	if isDirectJPY {
+		return p.fetchDirectRates(ctx, req)
+	}
+
+	return p.fetchCrossRates(ctx, req)
+}
+
+func (p *Provider) fetchDirectRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	jpyRates, report, err := p.fetchTable(ctx, req.Date)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	rates := filterTargets(jpyRates, req.Targets)
+	if err := common.ValidateRates(rates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+func (p *Provider) fetchCrossRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	// the table always lists every currency, so the base is already in it
+	jpyRates, report, err := p.fetchTable(ctx, req.Date)
+	if err != nil {
+		return models.RateResult{}, err
+	}
+
+	if err := common.ValidateRates(jpyRates); err != nil {
+		return models.RateResult{}, fmt.Errorf("validating jpy rates: %w", err)
+	}
+
+	rates, err := common.CalculateCrossRates(jpyRates, req.Base, req.Targets)
+	if err != nil {
+		return models.RateResult{}, fmt.Errorf("calculating cross rates: %w", err)
+	}
+
+	return common.NewRateResult(req.Targets, rates, p.SupportedCurrencies(), report), nil
+}
+
+func (p *Provider) fetchTable(ctx context.Context, date time.Time) ([]models.Rate, common.ParseReport, error) {
+	hasSpecificDate := !date.IsZero()
+	if hasSpecificDate {
+		if reason, closed := calendar.Japan.Closed(date); closed {
+			return nil, common.ParseReport{}, fmt.Errorf("no ttm table published on %s: %s", date.Format("2006-01-02"), reason)
+		}
+	}
+
+	url := p.buildURL(date)
+
+	body, err := p.client.Get(ctx, url)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("fetching from mufg: %w", err)
+	}
+
+	rates, report, err := p.parseTable(body, date)
+	if err != nil {
+		return nil, common.ParseReport{}, fmt.Errorf("parsing response: %w", err)
+	}
+	report.Warn(p.Name())
+
+	return rates, report, nil
+}
+
+func (p *Provider) buildURL(date time.Time) string {
+	hasSpecificDate := !date.IsZero()
+	if hasSpecificDate {
+		return fmt.Sprintf("%s/past/index.php?id=%s", p.baseURL, date.Format("060102"))
+	}
+
+	return p.baseURL + "/index.php"
+}
+
+// parseTable extracts TTM rates from the published table
+// the table quotes JPY per foreign unit, so we invert them for JPY-based queries
+func (p *Provider) parseTable(data []byte, requestDate time.Time) ([]models.Rate, common.ParseReport, error) {
+	var report common.ParseReport
+	page := string(data)
+
+	date, err := p.parsePublishedDate(page)
+	if err != nil {
+		return nil, report, err
+	}
+
+	hasSpecificDate := !requestDate.IsZero()
+	wrongDate := hasSpecificDate && !isSameDay(date, requestDate)
+	if wrongDate {
+		return nil, report, fmt.Errorf("table is for %s, requested %s", date.Format("2006-01-02"), requestDate.Format("2006-01-02"))
+	}
+
+	var rates []models.Rate
+	now := time.Now()
+
+	for n, row := range rowPattern.FindAllStringSubmatch(page, -1) {
+		cells := rowCells(row[1])
+		rate, skip, err := p.parseRow(cells, date, now)
+		if err != nil {
+			report.Skip(fmt.Sprintf("row %d", n+1), cells[2], err.Error())
+			continue
+		}
+		if skip {
+			continue
+		}
+		rates = append(rates, rate)
+	}
+
+	noData := (len(rates) == 0)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	report.Parsed = len(rates)
+	return rates, report, nil
+}
+
+func (p *Provider) parsePublishedDate(page string) (time.Time, error) {
+	match := datePattern.FindStringSubmatch(page)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("publication date not found")
+	}
+
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	day, _ := strconv.Atoi(match[3])
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), nil
+}
+
+func rowCells(row string) []string {
+	var cells []string
+	for _, cell := range cellPattern.FindAllStringSubmatch(row, -1) {
+		text := tagPattern.ReplaceAllString(cell[1], "")
+		cells = append(cells, strings.TrimSpace(html.UnescapeString(text)))
+	}
+	return cells
+}
+
+// parseRow reads one row of the fixed layout:
+// name (ja), name (en), code, TTS, TTB, TTM
+// rows that aren't a currency's and unquoted currencies are skipped quietly,
+// a TTM that isn't a positive number is malformed
+func (p *Provider) parseRow(cells []string, date time.Time, fetchedAt time.Time) (models.Rate, bool, error) {
+	insufficientColumns := (len(cells) < 6)
+	if insufficientColumns {
+		return models.Rate{}, true, nil
+	}
+
+	currency := cells[2]
+	if !codePattern.MatchString(currency) {
+		return models.Rate{}, true, nil
+	}
+
+	// unquoted currencies show a dash instead of a value
+	ttmStr := cells[5]
+	notQuoted := (ttmStr == "" || ttmStr == "-")
+	if notQuoted {
+		return models.Rate{}, true, nil
+	}
+
+	units := 1.0
+	if perHundred[currency] {
+		units = 100
+	}
+
+	ttm, err := strconv.ParseFloat(strings.ReplaceAll(ttmStr, ",", ""), 64)
+	value := units / ttm
+	invalidValue := (err != nil || !(ttm > 0) || math.IsInf(ttm, 0) || math.IsInf(value, 0))
+	if invalidValue {
+		return models.Rate{}, true, fmt.Errorf("invalid ttm %q", ttmStr)
+	}
+
+	return models.Rate{
+		Base:       "JPY",
+		Target:     currency,
+		Value:      value,
+		Date:       date,
+		Source:     "MUFG",
+		Fetched:    fetchedAt,
+		Calculated: false,
+		Frequency:  models.FrequencyDaily,
+	}, false, nil
+}
+
+func filterTargets(rates []models.Rate, targets []string) []models.Rate {
+	wanted := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		wanted[target] = true
+	}
+
+	var filtered []models.Rate
+	for _, rate := range rates {
+		if wanted[rate.Target] {
+			filtered = append(filtered, rate)
+		}
+	}
+	return filtered
+}
+
+func isSameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
+
+func (p *Provider) SupportedCurrencies() []string {
+	// currencies with a published TTM as of 2025
+	return []string{
+		"AUD", "CAD", "CHF", "CNY", "DKK", "EUR", "GBP",
+		"HKD", "IDR", "INR", "KRW", "MXN", "MYR", "NOK",
+		"NZD", "PHP", "SAR", "SEK", "SGD", "THB", "TWD",
+		"USD", "ZAR",
+	}
+}
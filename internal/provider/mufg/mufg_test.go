@@ -0,0 +1,115 @@
+package mufg
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+const samplePage = `
+<html><body>
+<p class="date">2025年10月17日 公示相場</p>
+<table class="data-table7">
+<tr><th>通貨名</th><th>英文名</th><th>略称</th><th>TTS</th><th>TTB</th><th>TTM</th></tr>
+<tr><td class="t_left">米ドル</td><td class="t_left">US Dollar</td><td>USD</td><td>151.50</td><td>149.50</td><td>150.50</td></tr>
+<tr><td class="t_left">ユーロ</td><td class="t_left">Euro</td><td>EUR</td><td>176.20</td><td>173.20</td><td>174.70</td></tr>
+<tr><td class="t_left">韓国ウォン</td><td class="t_left">Korean Won</td><td>KRW</td><td>11.10</td><td>10.30</td><td>10.70</td></tr>
+<tr><td class="t_left">ロシアルーブル</td><td class="t_left">Russian Ruble</td><td>RUB</td><td>-</td><td>-</td><td>-</td></tr>
+</table>
+</body></html>`
+
+func TestParseTable(t *testing.T) {
+	p := New()
+
+	rates, report, err := p.parseTable([]byte(samplePage), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(rates))
+	}
+
+	// RUB's dashes mean it isn't quoted, not that the row is malformed
+	if !report.Clean() || report.Parsed != 3 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	want := map[string]float64{
+		"USD": 1 / 150.50,
+		"EUR": 1 / 174.70,
+		"KRW": 100 / 10.70,
+	}
+
+	for _, r := range rates {
+		correctBase := r.Base == "JPY"
+		correctDate := r.Date.Equal(time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC))
+		correctValue := math.Abs(r.Value-want[r.Target]) < 1e-12
+
+		if !correctBase || !correctDate || !correctValue {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+
+	_, _, err = p.parseTable([]byte(samplePage), time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC))
+	if err == nil {
+		t.Error("expected error for mismatched date, got none")
+	}
+}
+
+func TestParseTableReport(t *testing.T) {
+	p := New()
+	page := strings.Replace(samplePage, "<td>174.70</td>", "<td>174.7O</td>", 1)
+
+	rates, report, err := p.parseTable([]byte(page), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) != 2 || report.Parsed != 2 {
+		t.Errorf("got %d rates and %s, want 2", len(rates), report)
+	}
+
+	skippedEUR := slices.Equal(report.SkippedCurrencies(), []string{"EUR"})
+	if !skippedEUR {
+		t.Errorf("expected the malformed EUR row to be reported: %s", report)
+	}
+}
+
+func TestFetchCrossRatesToBase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(samplePage))
+	}))
+	defer server.Close()
+
+	p := New(WithBaseURL(server.URL))
+
+	result, err := p.FetchRates(context.Background(), models.RateRequest{Base: "USD", Targets: []string{"JPY", "EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JPY is the table's own currency, so it comes from the USD row alone
+	want := map[string]float64{
+		"JPY": 150.50,
+		"EUR": 150.50 / 174.70,
+	}
+
+	if len(result.Rates) != len(want) {
+		t.Fatalf("got %d rates, want %d", len(result.Rates), len(want))
+	}
+
+	for _, r := range result.Rates {
+		correct := r.Base == "USD" && r.Calculated && math.Abs(r.Value-want[r.Target]) < 1e-9
+		if !correct {
+			t.Errorf("invalid rate: %+v", r)
+		}
+	}
+}
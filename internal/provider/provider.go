@@ -8,6 +8,19 @@ import (
 
 type Provider interface {
 	Name() string
-	FetchRates(ctx context.Context, req models.RateRequest) ([]models.Rate, error)
+	// Base is the currency the source natively quotes against
+	Base() string
+	// FetchRates fails when no rate could be fetched at all, otherwise the
+	// result explains every requested target that is missing
+	FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error)
 	SupportedCurrencies() []string
 }
+
+// Discoverer is implemented by providers that can list their live series catalogue
+type Discoverer interface {
+	Provider
+	// DiscoverCurrencies returns every published currency with its first and last observation
+	DiscoverCurrencies(ctx context.Context) ([]models.CurrencyInfo, error)
+	// SetSupportedCurrencies replaces the static fallback list
+	SetSupportedCurrencies(currencies []string)
+}
@@ -0,0 +1,530 @@
+// Package providertest is the conformance suite every provider has to pass
+// before it is wired in. It runs the provider against a fake upstream serving
+// a known fixture, then breaks that upstream the ways real ones break: empty
+// and malformed payloads, HTTP errors and requests that never answer.
+//
+// Each provider runs it from a TestConformance of its own, usually through
+// RunWith, and keeps the fake upstream next to it.
+package providertest
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// Unsupported is never published by the fixture, XXX is the ISO code for "no currency"
+const Unsupported = "XXX"
+
+// values are compared relative to the fixture, wire formats may round
+const tolerance = 1e-6
+
+// Fixture is everything the fake upstream publishes: a single day of rates
+type Fixture struct {
+	Date time.Time
+	// Rates holds units of each currency per one unit of the provider's base
+	Rates map[string]float64
+}
+
+// DefaultFixture leaves out every provider's base, so each of them can serve it
+var DefaultFixture = Fixture{
+	Date: time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC),
+	Rates: map[string]float64{
+		"GBP": 0.8693,
+		"CHF": 0.9314,
+		"AUD": 1.7934,
+		"SEK": 10.9865,
+	},
+}
+
+type Config struct {
+	// New builds the provider against the fixture server, using the given client
+	New func(baseURL string, client *common.HTTPClient) provider.Provider
+	// Upstream imitates the real API serving the fixture, including how it
+	// answers unknown currencies and days without data
+	Upstream func(f Fixture) http.Handler
+	// Empty is a well-formed payload without a single observation
+	Empty []byte
+	// Malformed adds format-specific broken payloads to the generic ones
+	Malformed [][]byte
+	// Fixture defaults to DefaultFixture
+	Fixture Fixture
+}
+
+// broken payloads any parser has to reject
+var genericMalformed = [][]byte{
+	[]byte("<html><body><h1>503 Service Unavailable</h1></body></html>"),
+	[]byte(`{"observations": [`),
+	{0x00, 0x01, 0xfe, 0xff, 0x1f, 0x8b},
+}
+
+type suite struct {
+	cfg     Config
+	fixture Fixture
+	// currencies of the fixture, sorted
+	currencies []string
+}
+
+// RunWith runs the suite against DefaultFixture, which is all most providers need
+func RunWith(t *testing.T, newProvider func(baseURL string, client *common.HTTPClient) provider.Provider, upstream func(f Fixture) http.Handler, empty []byte, malformed ...[]byte) {
+	t.Helper()
+
+	Run(t, Config{
+		New:       newProvider,
+		Upstream:  upstream,
+		Empty:     empty,
+		Malformed: malformed,
+	})
+}
+
+// CheckNotModified is for providers that honour RateRequest.IfModified: once
+// upstream has nothing new, asking again ends in common.ErrNotModified without
+// another request, while a plain request still gets rates
+func CheckNotModified(t *testing.T, newProvider func(baseURL string, client *common.HTTPClient) provider.Provider, upstream func(f Fixture) http.Handler) {
+	t.Helper()
+
+	var requests atomic.Int32
+	fake := upstream(DefaultFixture)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		// the content never changes, so one validator covers every URL
+		w.Header().Set("ETag", `"fixture"`)
+		isUnchanged := (r.Header.Get("If-None-Match") == `"fixture"`)
+		if isUnchanged {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	client := common.NewHTTPClient(5*time.Second,
+		common.WithRetry(common.RetryPolicy{MaxAttempts: 1}),
+		common.WithRateLimit(0, 0),
+	)
+	p := newProvider(srv.URL, client)
+
+	var targets []string
+	for currency := range DefaultFixture.Rates {
+		targets = append(targets, currency)
+	}
+	slices.Sort(targets)
+
+	req := models.RateRequest{Base: p.Base(), Targets: targets, Date: DefaultFixture.Date, IfModified: true}
+	if _, err := p.FetchRates(context.Background(), req); err != nil {
+		t.Fatalf("%s: %v", describe(req), err)
+	}
+
+	before := requests.Load()
+	if _, err := p.FetchRates(context.Background(), req); !errors.Is(err, common.ErrNotModified) {
+		t.Errorf("%s: expected common.ErrNotModified the second time, got %v", describe(req), err)
+	}
+	if extra := requests.Load() - before; extra != 1 {
+		t.Errorf("%s: got %d requests for an unchanged answer, want 1", describe(req), extra)
+	}
+
+	req.IfModified = false
+	result, err := p.FetchRates(context.Background(), req)
+	if err != nil || len(result.Rates) != len(targets) {
+		t.Errorf("%s: without IfModified got %d rates, %v", describe(req), len(result.Rates), err)
+	}
+}
+
+// Run checks the provider against the fixture and every failure mode
+func Run(t *testing.T, cfg Config) {
+	t.Helper()
+
+	s := &suite{cfg: cfg, fixture: cfg.Fixture}
+	if s.fixture.Rates == nil {
+		s.fixture = DefaultFixture
+	}
+
+	for currency := range s.fixture.Rates {
+		s.currencies = append(s.currencies, currency)
+	}
+	slices.Sort(s.currencies)
+
+	notEnoughCurrencies := (len(s.currencies) < 3)
+	if notEnoughCurrencies {
+		t.Fatal("fixture needs at least three currencies to test cross rates")
+	}
+
+	t.Run("direct rates", s.testDirect)
+	t.Run("cross rates", s.testCross)
+	t.Run("day without data", s.testMissingDay)
+	t.Run("unsupported currencies", s.testUnsupported)
+	t.Run("empty responses", s.testEmpty)
+	t.Run("malformed payloads", s.testMalformed)
+	t.Run("http errors", s.testHTTPErrors)
+	t.Run("context cancellation", s.testCancellation)
+}
+
+// start serves handler and builds a fresh provider against it
+func (s *suite) start(t *testing.T, handler http.Handler) provider.Provider {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	// one attempt and no rate limit, every failure here is deliberate
+	client := common.NewHTTPClient(5*time.Second,
+		common.WithRetry(common.RetryPolicy{MaxAttempts: 1}),
+		common.WithRateLimit(0, 0),
+	)
+
+	return s.cfg.New(srv.URL, client)
+}
+
+// serving answers every request with the same status and body
+func serving(status int, body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write(body)
+	})
+}
+
+func (s *suite) testDirect(t *testing.T) {
+	p := s.start(t, s.cfg.Upstream(s.fixture))
+
+	for _, date := range []time.Time{{}, s.fixture.Date} {
+		req := models.RateRequest{Base: p.Base(), Targets: s.currencies, Date: date}
+
+		result, err := p.FetchRates(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", describe(req), err)
+		}
+
+		s.checkResult(t, p, req, result)
+	}
+}
+
+func (s *suite) testCross(t *testing.T) {
+	p := s.start(t, s.cfg.Upstream(s.fixture))
+
+	for _, date := range []time.Time{{}, s.fixture.Date} {
+		// the provider's own base is a target too, it comes back as the inverse of the requested base
+		targets := append(slices.Clone(s.currencies[1:]), p.Base())
+		req := models.RateRequest{Base: s.currencies[0], Targets: targets, Date: date}
+
+		result, err := p.FetchRates(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", describe(req), err)
+		}
+
+		s.checkResult(t, p, req, result)
+	}
+}
+
+func (s *suite) testMissingDay(t *testing.T) {
+	p := s.start(t, s.cfg.Upstream(s.fixture))
+	req := models.RateRequest{
+		Base:    p.Base(),
+		Targets: s.currencies,
+		Date:    s.fixture.Date.AddDate(0, 0, -1),
+	}
+
+	result, err := p.FetchRates(context.Background(), req)
+	if err == nil {
+		t.Errorf("%s: got %d rates for a day the upstream has no data for, want an error", describe(req), len(result.Rates))
+	}
+}
+
+func (s *suite) testUnsupported(t *testing.T) {
+	p := s.start(t, s.cfg.Upstream(s.fixture))
+	ctx := context.Background()
+
+	onlyUnsupported := []models.RateRequest{
+		{Base: p.Base(), Targets: []string{Unsupported}},
+		{Base: Unsupported, Targets: s.currencies},
+	}
+	for _, req := range onlyUnsupported {
+		result, err := p.FetchRates(ctx, req)
+		if err == nil {
+			t.Errorf("%s: got %d rates, want an error", describe(req), len(result.Rates))
+		}
+	}
+
+	// upstreams either reject the whole request or leave the currency out,
+	// both are fine as long as nothing is made up for it and the result says why
+	missing := map[string]string{Unsupported: models.StatusUnsupported}
+	if unpublished := s.unpublished(p); unpublished != "" {
+		missing[unpublished] = models.StatusNotPublished
+	}
+
+	for currency, status := range missing {
+		req := models.RateRequest{Base: p.Base(), Targets: []string{s.currencies[0], currency}}
+		result, err := p.FetchRates(ctx, req)
+		if err != nil {
+			continue
+		}
+
+		s.checkResult(t, p, req, result)
+		if got := result.Status(currency); got != status {
+			t.Errorf("%s: %s has status %q, want %q", describe(req), currency, got, status)
+		}
+	}
+}
+
+// unpublished picks a currency the provider supports but the fixture leaves out
+func (s *suite) unpublished(p provider.Provider) string {
+	for _, currency := range p.SupportedCurrencies() {
+		_, published := s.fixture.Rates[currency]
+		if !published && currency != p.Base() {
+			return currency
+		}
+	}
+	return ""
+}
+
+func (s *suite) testEmpty(t *testing.T) {
+	bodies := [][]byte{nil}
+	if s.cfg.Empty != nil {
+		bodies = append(bodies, s.cfg.Empty)
+	}
+
+	for _, body := range bodies {
+		s.expectFailure(t, serving(http.StatusOK, body), "empty payload "+quote(body), nil)
+	}
+}
+
+func (s *suite) testMalformed(t *testing.T) {
+	payloads := append(slices.Clone(genericMalformed), s.cfg.Malformed...)
+
+	for _, body := range payloads {
+		s.expectFailure(t, serving(http.StatusOK, body), "malformed payload "+quote(body), nil)
+	}
+}
+
+func (s *suite) testHTTPErrors(t *testing.T) {
+	statuses := []struct {
+		code int
+		kind error
+	}{
+		{http.StatusBadRequest, nil},
+		{http.StatusForbidden, nil},
+		{http.StatusNotFound, common.ErrNotFound},
+		{http.StatusTooManyRequests, common.ErrRateLimited},
+		{http.StatusInternalServerError, common.ErrUpstream},
+		{http.StatusServiceUnavailable, common.ErrUpstream},
+	}
+
+	for _, status := range statuses {
+		body := []byte(http.StatusText(status.code))
+		s.expectFailure(t, serving(status.code, body), http.StatusText(status.code), status.kind)
+	}
+}
+
+// expectFailure fetches direct and cross rates from handler, both have to fail
+// with an error matching kind when one is given
+func (s *suite) expectFailure(t *testing.T, handler http.Handler, what string, kind error) {
+	t.Helper()
+
+	p := s.start(t, handler)
+	requests := []models.RateRequest{
+		{Base: p.Base(), Targets: s.currencies},
+		{Base: s.currencies[0], Targets: s.currencies[1:], Date: s.fixture.Date},
+	}
+
+	for _, req := range requests {
+		result, err := p.FetchRates(context.Background(), req)
+		if err == nil {
+			t.Errorf("%s, %s: got %d rates, want an error", what, describe(req), len(result.Rates))
+			continue
+		}
+
+		wrongKind := (kind != nil && !errors.Is(err, kind))
+		if wrongKind {
+			t.Errorf("%s, %s: got %v, want it to wrap %v", what, describe(req), err, kind)
+		}
+	}
+}
+
+func (s *suite) testCancellation(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	hang := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+
+	p := s.start(t, hang)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.FetchRates(ctx, models.RateRequest{Base: p.Base(), Targets: s.currencies})
+		done <- err
+	}()
+
+	select {
+	case <-arrived:
+	case <-time.After(5 * time.Second):
+		t.Fatal("provider never reached the upstream")
+	}
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want it to wrap context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("provider kept waiting after the context was cancelled")
+	}
+}
+
+// CheckParsed is what fuzz targets assert on a parser's output: the report
+// accounts for exactly the rates returned and each of them is complete. Dates
+// and pairs are left to common.ValidateRates, which runs after parsing.
+func CheckParsed(t *testing.T, rates []models.Rate, report common.ParseReport) {
+	t.Helper()
+
+	if report.Parsed != len(rates) {
+		t.Errorf("report counts %d parsed, got %d rates", report.Parsed, len(rates))
+	}
+
+	for _, rate := range rates {
+		validValue := rate.Value > 0 && !math.IsInf(rate.Value, 0)
+		complete := rate.Base != "" && rate.Target != "" && rate.Source != "" && !rate.Date.IsZero()
+
+		if !validValue || !complete {
+			t.Errorf("invalid rate: %+v", rate)
+		}
+	}
+
+	for _, skipped := range report.Skipped {
+		if skipped.Record == "" || skipped.Reason == "" {
+			t.Errorf("skipped record without location or reason: %+v", skipped)
+		}
+	}
+}
+
+// checkResult compares a successful answer against the fixture: published
+// targets come back with the fixture's values, the others are explained
+func (s *suite) checkResult(t *testing.T, p provider.Provider, req models.RateRequest, result models.RateResult) {
+	t.Helper()
+
+	if len(result.Targets) != len(req.Targets) {
+		t.Errorf("%s: got %d outcomes, want one per target", describe(req), len(result.Targets))
+	}
+
+	var published []string
+	for i, target := range req.Targets {
+		_, isPublished := s.fixtureRate(p, target)
+		if isPublished {
+			published = append(published, target)
+		}
+
+		explained := (i < len(result.Targets) && result.Targets[i].Currency == target)
+		if !explained {
+			t.Errorf("%s: no outcome for %s in %+v", describe(req), target, result.Targets)
+			continue
+		}
+
+		returned := (result.Targets[i].Status == models.StatusReturned)
+		if returned != isPublished {
+			t.Errorf("%s: %s has status %q", describe(req), target, result.Targets[i].Status)
+		}
+	}
+
+	rates := result.Rates
+
+	if err := common.ValidateRates(rates); err != nil {
+		t.Errorf("%s: %v", describe(req), err)
+	}
+
+	wantCount := len(published)
+	if len(rates) != wantCount {
+		t.Errorf("%s: got %d rates, want %d", describe(req), len(rates), wantCount)
+	}
+
+	isCross := (req.Base != p.Base())
+	seen := make(map[string]bool)
+
+	for _, rate := range rates {
+		requested := slices.Contains(published, rate.Target)
+		duplicate := seen[rate.Target]
+		seen[rate.Target] = true
+
+		if !requested || duplicate {
+			t.Errorf("%s: unexpected rate %+v", describe(req), rate)
+			continue
+		}
+
+		correctBase := (rate.Base == req.Base)
+		correctSource := (rate.Source == p.Name())
+		correctDate := sameDay(rate.Date, s.fixture.Date)
+		correctKind := (rate.Calculated == isCross)
+		hasFrequency := (rate.Frequency != "")
+
+		if !correctBase || !correctSource || !correctDate || !correctKind || !hasFrequency {
+			t.Errorf("%s: invalid rate %+v", describe(req), rate)
+		}
+
+		want, _ := s.fixtureRate(p, rate.Target)
+		if isCross {
+			want /= s.fixture.Rates[req.Base]
+		}
+
+		correctValue := (math.Abs(rate.Value/want-1) < tolerance)
+		if !correctValue {
+			t.Errorf("%s: %s/%s = %v, want %v", describe(req), rate.Base, rate.Target, rate.Value, want)
+		}
+	}
+}
+
+// fixtureRate is the fixture's rate against the provider's base, which is 1 against itself
+func (s *suite) fixtureRate(p provider.Provider, currency string) (float64, bool) {
+	isBase := (currency == p.Base())
+	if isBase {
+		return 1, true
+	}
+
+	rate, ok := s.fixture.Rates[currency]
+	return rate, ok
+}
+
+func describe(req models.RateRequest) string {
+	when := "latest"
+	if !req.Date.IsZero() {
+		when = req.Date.Format("2006-01-02")
+	}
+	return req.Base + "->" + strings.Join(req.Targets, ",") + " " + when
+}
+
+// quote keeps binary payloads readable in failure messages
+func quote(body []byte) string {
+	const maxLength = 32
+	if len(body) > maxLength {
+		body = body[:maxLength]
+	}
+	return strconv.Quote(string(body))
+}
+
+func sameDay(t1, t2 time.Time) bool {
+	y1, m1, d1 := t1.Date()
+	y2, m2, d2 := t2.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
@@ -0,0 +1,49 @@
+package replay
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Write([]byte("TIME_PERIOD,OBS_VALUE\n2025-10-17,1.1681\n"))
+	}))
+	url := server.URL + "/data/EXR/D.USD.EUR.SP00.A?lastNObservations=1"
+
+	t.Run("record", func(t *testing.T) {
+		t.Setenv(RecordEnv, "1")
+
+		body, err := HTTPClient(dir).Get(ctx, url)
+		if err != nil || len(body) == 0 {
+			t.Fatalf("got %q, %v", body, err)
+		}
+
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Fatalf("got %d fixtures, want 1", len(entries))
+		}
+	})
+
+	server.Close()
+
+	t.Run("replay without the server", func(t *testing.T) {
+		body, err := HTTPClient(dir).Get(ctx, url)
+		if err != nil || string(body) != "TIME_PERIOD,OBS_VALUE\n2025-10-17,1.1681\n" {
+			t.Errorf("got %q, %v", body, err)
+		}
+	})
+
+	t.Run("missing fixture", func(t *testing.T) {
+		if _, err := HTTPClient(dir).Get(ctx, url+"&other=1"); err == nil {
+			t.Error("expected an error for an unrecorded request")
+		}
+	})
+}
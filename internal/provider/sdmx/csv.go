@@ -0,0 +1,116 @@
+package sdmx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// columns that are neither dimensions nor attributes
+var csvMetaColumns = map[string]bool{
+	"DATAFLOW": true,
+	"KEY":      true,
+}
+
+// ParseCSV reads SDMX-CSV 1.0: dimension columns come before TIME_PERIOD,
+// then OBS_VALUE, then attributes. Labelled headers ("FREQ:Frequency") and
+// values ("D: Daily") are reduced to their codes.
+func ParseCSV(data []byte) ([]Observation, common.ParseReport, error) {
+	var report common.ParseReport
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, report, fmt.Errorf("reading csv: %w", err)
+	}
+
+	noData := (len(records) < 2)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	header := make([]string, len(records[0]))
+	labelled := false
+	for i, col := range records[0] {
+		code, _, hasLabel := strings.Cut(col, ":")
+		header[i] = strings.TrimSpace(code)
+		labelled = labelled || hasLabel
+	}
+
+	periodIdx := indexOf(header, timePeriod)
+	valueIdx := indexOf(header, "OBS_VALUE")
+
+	missingColumns := (periodIdx == -1 || valueIdx == -1)
+	if missingColumns {
+		return nil, report, fmt.Errorf("missing required csv columns")
+	}
+
+	var observations []Observation
+	for n, record := range records[1:] {
+		row := fmt.Sprintf("row %d", n+1)
+
+		insufficientColumns := (len(record) < len(header))
+		if insufficientColumns {
+			report.Skip(row, "", fmt.Sprintf("%d columns, header has %d", len(record), len(header)))
+			continue
+		}
+
+		obs := Observation{
+			SeriesKey:  make(map[string]string),
+			TimePeriod: strings.TrimSpace(record[periodIdx]),
+			Attributes: make(map[string]string),
+		}
+
+		for i, col := range header {
+			isSpecial := (i == periodIdx || i == valueIdx || csvMetaColumns[col])
+			if isSpecial {
+				continue
+			}
+
+			cell := record[i]
+			if labelled {
+				cell, _, _ = strings.Cut(cell, ":")
+			}
+			cell = strings.TrimSpace(cell)
+
+			isDimension := (i < periodIdx)
+			if isDimension {
+				obs.SeriesKey[col] = cell
+				continue
+			}
+
+			if cell != "" {
+				obs.Attributes[col] = cell
+			}
+		}
+
+		value, ok, err := parseValue(record[valueIdx])
+		if err != nil {
+			report.Skip(row, currencyOf(obs.SeriesKey), err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		obs.Value = value
+		observations = append(observations, obs)
+	}
+
+	report.Parsed = len(observations)
+	return observations, report, nil
+}
+
+func indexOf(header []string, column string) int {
+	for i, col := range header {
+		if col == column {
+			return i
+		}
+	}
+	return -1
+}
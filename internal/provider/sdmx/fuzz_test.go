@@ -0,0 +1,64 @@
+package sdmx
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// checkParsed holds for every parser: no panics, an accurate report and only
+// finite values
+func checkParsed(t *testing.T, observations []Observation, report common.ParseReport, err error) {
+	if err != nil {
+		return
+	}
+
+	if report.Parsed != len(observations) {
+		t.Errorf("report counts %d parsed, got %d observations", report.Parsed, len(observations))
+	}
+
+	for _, obs := range observations {
+		finite := !math.IsNaN(obs.Value) && !math.IsInf(obs.Value, 0)
+		if !finite {
+			t.Errorf("non-finite value in %+v", obs)
+		}
+	}
+
+	for _, skipped := range report.Skipped {
+		if skipped.Record == "" || skipped.Reason == "" {
+			t.Errorf("skipped record without location or reason: %+v", skipped)
+		}
+	}
+}
+
+func FuzzParseCSV(f *testing.F) {
+	f.Add([]byte(sampleCSV))
+	f.Add([]byte("TIME_PERIOD,OBS_VALUE\n2025-10-17,1e400\n"))
+	f.Add([]byte("FREQ:Frequency,TIME_PERIOD,OBS_VALUE\nD: Daily,2025-10-17,\"1.1\"\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		observations, report, err := ParseCSV(data)
+		checkParsed(t, observations, report, err)
+	})
+}
+
+func FuzzParseJSON(f *testing.F) {
+	f.Add([]byte(sampleJSON))
+	f.Add([]byte(`{"dataSets": [{"observations": {"0": ["NaN"]}}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		observations, report, err := ParseJSON(data)
+		checkParsed(t, observations, report, err)
+	})
+}
+
+func FuzzParseGeneric(f *testing.F) {
+	f.Add([]byte(sampleGeneric))
+	f.Add([]byte(`<GenericData><DataSet><Obs><ObsValue value="x"/></Obs></DataSet></GenericData>`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		observations, report, err := ParseGeneric(data)
+		checkParsed(t, observations, report, err)
+	})
+}
@@ -0,0 +1,127 @@
+package sdmx
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// element names are matched without namespaces, the message/generic
+// prefixes vary between agencies
+
+type genericMessage struct {
+	DataSets []genericDataSet `xml:"DataSet"`
+}
+
+type genericDataSet struct {
+	Series []genericSeries `xml:"Series"`
+	// flat observations, used when dimensionAtObservation=AllDimensions
+	Obs []genericObs `xml:"Obs"`
+}
+
+type genericSeries struct {
+	SeriesKey  []genericValue `xml:"SeriesKey>Value"`
+	Attributes []genericValue `xml:"Attributes>Value"`
+	Obs        []genericObs   `xml:"Obs"`
+}
+
+type genericObs struct {
+	Dimension  genericValue   `xml:"ObsDimension"`
+	ObsKey     []genericValue `xml:"ObsKey>Value"`
+	Value      genericValue   `xml:"ObsValue"`
+	Attributes []genericValue `xml:"Attributes>Value"`
+}
+
+type genericValue struct {
+	ID    string `xml:"id,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// ParseGeneric reads SDMX-ML 2.1 generic data messages
+func ParseGeneric(data []byte) ([]Observation, common.ParseReport, error) {
+	var report common.ParseReport
+
+	var msg genericMessage
+	if err := xml.Unmarshal(data, &msg); err != nil {
+		return nil, report, fmt.Errorf("decoding xml: %w", err)
+	}
+
+	noData := (len(msg.DataSets) == 0)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	var observations []Observation
+	for d, ds := range msg.DataSets {
+		for s, series := range ds.Series {
+			key := valuesToMap(series.SeriesKey)
+			attrs := valuesToMap(series.Attributes)
+
+			for n, o := range series.Obs {
+				obs, ok, err := o.toObservation()
+				if err != nil {
+					record := fmt.Sprintf("dataset %d series %d observation %d", d+1, s+1, n+1)
+					report.Skip(record, currencyOf(key), err.Error())
+					continue
+				}
+				if !ok {
+					continue
+				}
+
+				mergeInto(obs.SeriesKey, key)
+				mergeInto(obs.Attributes, attrs)
+				observations = append(observations, obs)
+			}
+		}
+
+		for n, o := range ds.Obs {
+			obs, ok, err := o.toObservation()
+			if err != nil {
+				record := fmt.Sprintf("dataset %d observation %d", d+1, n+1)
+				report.Skip(record, currencyOf(obs.SeriesKey), err.Error())
+				continue
+			}
+			if ok {
+				observations = append(observations, obs)
+			}
+		}
+	}
+
+	report.Parsed = len(observations)
+	return observations, report, nil
+}
+
+// toObservation returns the observation's key even when its value is malformed, for the report
+func (o genericObs) toObservation() (Observation, bool, error) {
+	obs := Observation{
+		SeriesKey:  make(map[string]string),
+		TimePeriod: o.Dimension.Value,
+		Attributes: valuesToMap(o.Attributes),
+	}
+
+	for _, v := range o.ObsKey {
+		isTime := (v.ID == timePeriod)
+		if isTime {
+			obs.TimePeriod = v.Value
+			continue
+		}
+		obs.SeriesKey[v.ID] = v.Value
+	}
+
+	value, ok, err := parseValue(o.Value.Value)
+	if err != nil || !ok {
+		return obs, false, err
+	}
+
+	obs.Value = value
+	return obs, true, nil
+}
+
+func valuesToMap(values []genericValue) map[string]string {
+	result := make(map[string]string, len(values))
+	for _, v := range values {
+		result[v.ID] = v.Value
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package sdmx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+type jsonMessage struct {
+	DataSets  []jsonDataSet `json:"dataSets"`
+	Structure jsonStructure `json:"structure"`
+}
+
+type jsonDataSet struct {
+	Series map[string]jsonSeries `json:"series"`
+	// present instead of series when dimensionAtObservation=AllDimensions
+	Observations map[string][]any `json:"observations"`
+}
+
+type jsonSeries struct {
+	Attributes   []*int           `json:"attributes"`
+	Observations map[string][]any `json:"observations"`
+}
+
+type jsonStructure struct {
+	Dimensions struct {
+		Series      []jsonComponent `json:"series"`
+		Observation []jsonComponent `json:"observation"`
+	} `json:"dimensions"`
+	Attributes struct {
+		Series      []jsonComponent `json:"series"`
+		Observation []jsonComponent `json:"observation"`
+	} `json:"attributes"`
+}
+
+type jsonComponent struct {
+	ID     string           `json:"id"`
+	Values []jsonValueEntry `json:"values"`
+}
+
+type jsonValueEntry struct {
+	ID    string `json:"id"`
+	Name  any    `json:"name"`
+	Value any    `json:"value"`
+}
+
+// ParseJSON reads SDMX-JSON 1.0 data messages, where series and observations
+// are keyed by colon-separated indices into the structure's value lists
+func ParseJSON(data []byte) ([]Observation, common.ParseReport, error) {
+	var report common.ParseReport
+
+	var msg jsonMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, report, fmt.Errorf("decoding json: %w", err)
+	}
+
+	noData := (len(msg.DataSets) == 0)
+	if noData {
+		return nil, report, fmt.Errorf("no data in response")
+	}
+
+	structure := msg.Structure
+
+	var observations []Observation
+	for _, ds := range msg.DataSets {
+		for seriesKey, series := range ds.Series {
+			key, err := decodeKey(seriesKey, structure.Dimensions.Series)
+			if err != nil {
+				return nil, report, fmt.Errorf("series %s: %w", seriesKey, err)
+			}
+
+			seriesAttrs := decodeAttributes(series.Attributes, structure.Attributes.Series)
+
+			for obsKey, values := range series.Observations {
+				obs, ok, err := decodeObservation(obsKey, values, structure)
+				if err != nil {
+					report.Skip(fmt.Sprintf("series %s observation %s", seriesKey, obsKey), currencyOf(key), err.Error())
+					continue
+				}
+				if !ok {
+					continue
+				}
+
+				mergeInto(obs.SeriesKey, key)
+				mergeInto(obs.Attributes, seriesAttrs)
+				observations = append(observations, obs)
+			}
+		}
+
+		for obsKey, values := range ds.Observations {
+			obs, ok, err := decodeObservation(obsKey, values, structure)
+			if err != nil {
+				report.Skip(fmt.Sprintf("observation %s", obsKey), currencyOf(obs.SeriesKey), err.Error())
+				continue
+			}
+			if ok {
+				observations = append(observations, obs)
+			}
+		}
+	}
+
+	report.Parsed = len(observations)
+	return observations, report, nil
+}
+
+// decodeObservation reads [value, attrIndex...] keyed by observation dimension indices,
+// a malformed value still returns the observation's key for the report
+func decodeObservation(obsKey string, values []any, structure jsonStructure) (Observation, bool, error) {
+	dims, err := decodeKey(obsKey, structure.Dimensions.Observation)
+	if err != nil {
+		return Observation{}, false, fmt.Errorf("observation %s: %w", obsKey, err)
+	}
+
+	obs := Observation{
+		SeriesKey:  make(map[string]string),
+		TimePeriod: dims[timePeriod],
+	}
+
+	for id, code := range dims {
+		isTime := (id == timePeriod)
+		if !isTime {
+			obs.SeriesKey[id] = code
+		}
+	}
+
+	noValue := (len(values) == 0)
+	if noValue {
+		return obs, false, nil
+	}
+
+	value, ok, err := jsonNumber(values[0])
+	if err != nil || !ok {
+		return obs, false, err
+	}
+
+	var attrIndices []*int
+	for _, raw := range values[1:] {
+		idx, isIndex, _ := jsonNumber(raw)
+		if !isIndex {
+			attrIndices = append(attrIndices, nil)
+			continue
+		}
+		i := int(idx)
+		attrIndices = append(attrIndices, &i)
+	}
+
+	obs.Value = value
+	obs.Attributes = decodeAttributes(attrIndices, structure.Attributes.Observation)
+	return obs, true, nil
+}
+
+func decodeKey(key string, components []jsonComponent) (map[string]string, error) {
+	result := make(map[string]string)
+
+	emptyKey := (key == "")
+	if emptyKey {
+		return result, nil
+	}
+
+	parts := strings.Split(key, ":")
+	wrongLength := (len(parts) != len(components))
+	if wrongLength {
+		return nil, fmt.Errorf("key has %d positions, structure has %d", len(parts), len(components))
+	}
+
+	for i, part := range parts {
+		idx, err := strconv.Atoi(part)
+		outOfRange := (err != nil || idx < 0 || idx >= len(components[i].Values))
+		if outOfRange {
+			return nil, fmt.Errorf("invalid index %q for %s", part, components[i].ID)
+		}
+		result[components[i].ID] = components[i].Values[idx].code()
+	}
+
+	return result, nil
+}
+
+func decodeAttributes(indices []*int, components []jsonComponent) map[string]string {
+	result := make(map[string]string)
+
+	for i, idx := range indices {
+		outOfRange := (idx == nil || i >= len(components) || *idx < 0 || *idx >= len(components[i].Values))
+		if outOfRange {
+			continue
+		}
+		result[components[i].ID] = components[i].Values[*idx].code()
+	}
+
+	return result
+}
+
+// code prefers the coded id, uncoded attributes only carry a name or value
+func (v jsonValueEntry) code() string {
+	if v.ID != "" {
+		return v.ID
+	}
+	for _, candidate := range []any{v.Value, v.Name} {
+		switch typed := candidate.(type) {
+		case string:
+			return typed
+		case float64:
+			return strconv.FormatFloat(typed, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// jsonNumber follows parseValue: null is missing, non-numeric types are malformed
+func jsonNumber(v any) (float64, bool, error) {
+	switch typed := v.(type) {
+	case nil:
+		return 0, false, nil
+	case float64:
+		return typed, true, nil
+	case string:
+		return parseValue(typed)
+	}
+	return 0, false, fmt.Errorf("invalid value %v", v)
+}
+
+func mergeInto(dst, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
@@ -0,0 +1,196 @@
+// Package sdmx is a small SDMX 2.1 REST client shared by statistical-agency providers.
+// It builds data queries and parses SDMX-CSV, SDMX-JSON and SDMX-ML generic data
+// into a flat list of observations, leaving the mapping to rates to each provider.
+package sdmx
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatJSON    Format = "json"
+	FormatGeneric Format = "generic"
+)
+
+// media types defined by the SDMX 2.1 REST specification
+var acceptHeaders = map[Format]string{
+	FormatCSV:     "application/vnd.sdmx.data+csv;version=1.0.0",
+	FormatJSON:    "application/vnd.sdmx.data+json;version=1.0.0",
+	FormatGeneric: "application/vnd.sdmx.genericdata+xml;version=2.1",
+}
+
+// well-known attributes
+const (
+	AttrUnitMult  = "UNIT_MULT"
+	AttrObsStatus = "OBS_STATUS"
+	AttrDecimals  = "DECIMALS"
+)
+
+const timePeriod = "TIME_PERIOD"
+
+type Client struct {
+	baseURL string
+	client  *common.HTTPClient
+}
+
+// Query describes a data request. Key holds one entry per dimension in
+// data structure order, each a set of codes joined with "+"; an empty entry is a wildcard.
+type Query struct {
+	Flow               string
+	Key                [][]string
+	StartPeriod        string
+	EndPeriod          string
+	UpdatedAfter       time.Time
+	FirstNObservations int
+	LastNObservations  int
+	Detail             string
+	// IfModified answers a 304 with common.ErrNotModified, see models.RateRequest
+	IfModified bool
+}
+
+type Observation struct {
+	// SeriesKey maps dimension ids to codes, e.g. "CURRENCY" -> "USD"
+	SeriesKey  map[string]string
+	TimePeriod string
+	Value      float64
+	// Attributes holds both series and observation level attributes
+	Attributes map[string]string
+}
+
+func NewClient(baseURL string, client *common.HTTPClient) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// Data fetches and parses a query, the report lists observations that could not be read
+func (c *Client) Data(ctx context.Context, q Query, format Format) ([]Observation, common.ParseReport, error) {
+	accept, ok := acceptHeaders[format]
+	if !ok {
+		return nil, common.ParseReport{}, fmt.Errorf("unsupported format %q", format)
+	}
+
+	body, err := c.client.FetchBody(ctx, c.URL(q), map[string]string{"Accept": accept}, q.IfModified)
+	if err != nil {
+		return nil, common.ParseReport{}, err
+	}
+
+	return Parse(body, format)
+}
+
+func (c *Client) URL(q Query) string {
+	u := fmt.Sprintf("%s/data/%s/%s", c.baseURL, q.Flow, q.SeriesKey())
+
+	params := url.Values{}
+	if q.StartPeriod != "" {
+		params.Set("startPeriod", q.StartPeriod)
+	}
+	if q.EndPeriod != "" {
+		params.Set("endPeriod", q.EndPeriod)
+	}
+	if !q.UpdatedAfter.IsZero() {
+		params.Set("updatedAfter", q.UpdatedAfter.UTC().Format(time.RFC3339))
+	}
+	if q.FirstNObservations > 0 {
+		params.Set("firstNObservations", strconv.Itoa(q.FirstNObservations))
+	}
+	if q.LastNObservations > 0 {
+		params.Set("lastNObservations", strconv.Itoa(q.LastNObservations))
+	}
+	if q.Detail != "" {
+		params.Set("detail", q.Detail)
+	}
+
+	hasParams := (len(params) > 0)
+	if hasParams {
+		u += "?" + params.Encode()
+	}
+
+	return u
+}
+
+// SeriesKey renders the key as e.g. "D.USD+JPY.EUR.SP00.A"
+func (q Query) SeriesKey() string {
+	noKey := (len(q.Key) == 0)
+	if noKey {
+		return "all"
+	}
+
+	parts := make([]string, len(q.Key))
+	for i, codes := range q.Key {
+		parts[i] = strings.Join(codes, "+")
+	}
+	return strings.Join(parts, ".")
+}
+
+func Parse(data []byte, format Format) ([]Observation, common.ParseReport, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(data)
+	case FormatJSON:
+		return ParseJSON(data)
+	case FormatGeneric:
+		return ParseGeneric(data)
+	}
+
+	return nil, common.ParseReport{}, fmt.Errorf("unsupported format %q", format)
+}
+
+// ScaledValue applies UNIT_MULT, so a value of 1.5 with UNIT_MULT 3 becomes 1500
+func (o Observation) ScaledValue() float64 {
+	mult, err := strconv.Atoi(o.Attributes[AttrUnitMult])
+	if err != nil {
+		return o.Value
+	}
+	return o.Value * math.Pow10(mult)
+}
+
+// Date parses daily periods as-is and dates coarser periods at their first day
+func (o Observation) Date() (time.Time, error) {
+	layouts := []string{"2006-01-02", "2006-01", "2006"}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, o.TimePeriod); err == nil {
+			return date, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time period %q", o.TimePeriod)
+}
+
+// parseValue treats empty and NaN values as missing, anything else that is
+// not a finite number as malformed
+func parseValue(s string) (float64, bool, error) {
+	trimmed := strings.TrimSpace(s)
+	isEmpty := (trimmed == "")
+	if isEmpty {
+		return 0, false, nil
+	}
+
+	value, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || math.IsInf(value, 0) {
+		return 0, false, fmt.Errorf("invalid value %q", trimmed)
+	}
+
+	if math.IsNaN(value) {
+		return 0, false, nil
+	}
+	return value, true, nil
+}
+
+// currencyOf attributes a skipped observation for the report, most exchange
+// rate flows carry a CURRENCY dimension
+func currencyOf(key map[string]string) string {
+	return key["CURRENCY"]
+}
@@ -0,0 +1,222 @@
+package sdmx
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleCSV = `KEY,FREQ,CURRENCY,CURRENCY_DENOM,EXR_TYPE,EXR_SUFFIX,TIME_PERIOD,OBS_VALUE,OBS_STATUS,UNIT_MULT,TITLE
+EXR.D.USD.EUR.SP00.A,D,USD,EUR,SP00,A,2025-10-17,1.1681,A,0,"US dollar/Euro, ECB reference"
+EXR.D.JPY.EUR.SP00.A,D,JPY,EUR,SP00,A,2025-10-17,175.48,A,0,Japanese yen/Euro
+EXR.D.RUB.EUR.SP00.A,D,RUB,EUR,SP00,A,2025-10-17,,,0,Russian rouble/Euro
+`
+
+const sampleJSON = `{
+  "dataSets": [{
+    "series": {
+      "0:0": {"attributes": [0], "observations": {"0": [1.1681, 0], "1": [1.1702, null]}},
+      "0:1": {"attributes": [1], "observations": {"1": [175.48, 0]}}
+    }
+  }],
+  "structure": {
+    "dimensions": {
+      "series": [
+        {"id": "FREQ", "values": [{"id": "D"}]},
+        {"id": "CURRENCY", "values": [{"id": "USD"}, {"id": "JPY"}]}
+      ],
+      "observation": [
+        {"id": "TIME_PERIOD", "values": [{"id": "2025-10-16"}, {"id": "2025-10-17"}]}
+      ]
+    },
+    "attributes": {
+      "series": [{"id": "UNIT_MULT", "values": [{"id": "0"}, {"id": "2"}]}],
+      "observation": [{"id": "OBS_STATUS", "values": [{"id": "A"}]}]
+    }
+  }
+}`
+
+const sampleGeneric = `<?xml version="1.0" encoding="UTF-8"?>
+<message:GenericData xmlns:message="http://www.sdmx.org/resources/sdmxml/schemas/v2_1/message" xmlns:generic="http://www.sdmx.org/resources/sdmxml/schemas/v2_1/data/generic">
+  <message:DataSet>
+    <generic:Series>
+      <generic:SeriesKey>
+        <generic:Value id="FREQ" value="D"/>
+        <generic:Value id="CURRENCY" value="USD"/>
+      </generic:SeriesKey>
+      <generic:Attributes>
+        <generic:Value id="DECIMALS" value="4"/>
+      </generic:Attributes>
+      <generic:Obs>
+        <generic:ObsDimension value="2025-10-17"/>
+        <generic:ObsValue value="1.1681"/>
+        <generic:Attributes>
+          <generic:Value id="OBS_STATUS" value="A"/>
+        </generic:Attributes>
+      </generic:Obs>
+      <generic:Obs>
+        <generic:ObsDimension value="2025-10-18"/>
+        <generic:ObsValue value="NaN"/>
+      </generic:Obs>
+    </generic:Series>
+  </message:DataSet>
+</message:GenericData>`
+
+func TestParseCSV(t *testing.T) {
+	observations, report, err := ParseCSV([]byte(sampleCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// missing values are not malformed
+	if !report.Clean() || report.Parsed != 2 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	if len(observations) != 2 {
+		t.Fatalf("got %d observations, want 2", len(observations))
+	}
+
+	usd := observations[0]
+	correctKey := usd.SeriesKey["CURRENCY"] == "USD" && usd.SeriesKey["FREQ"] == "D"
+	correctValue := usd.Value == 1.1681 && usd.TimePeriod == "2025-10-17"
+	correctAttrs := usd.Attributes[AttrObsStatus] == "A" && usd.Attributes["TITLE"] == "US dollar/Euro, ECB reference"
+	keyNotAttribute := usd.Attributes["CURRENCY"] == "" && usd.SeriesKey["KEY"] == ""
+
+	if !correctKey || !correctValue || !correctAttrs || !keyNotAttribute {
+		t.Errorf("invalid observation: %+v", usd)
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	observations, report, err := ParseJSON([]byte(sampleJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// missing values are not malformed
+	if !report.Clean() || report.Parsed != 3 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	if len(observations) != 3 {
+		t.Fatalf("got %d observations, want 3", len(observations))
+	}
+
+	for _, obs := range observations {
+		isJPY := obs.SeriesKey["CURRENCY"] == "JPY"
+		if !isJPY {
+			continue
+		}
+
+		correctPeriod := obs.TimePeriod == "2025-10-17"
+		correctScale := obs.ScaledValue() == 17548
+		correctStatus := obs.Attributes[AttrObsStatus] == "A"
+
+		if !correctPeriod || !correctScale || !correctStatus {
+			t.Errorf("invalid observation: %+v", obs)
+		}
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	observations, report, err := ParseGeneric([]byte(sampleGeneric))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// missing values are not malformed
+	if !report.Clean() || report.Parsed != 1 {
+		t.Errorf("unexpected report: %s", report)
+	}
+
+	if len(observations) != 1 {
+		t.Fatalf("got %d observations, want 1", len(observations))
+	}
+
+	obs := observations[0]
+	correctKey := obs.SeriesKey["CURRENCY"] == "USD"
+	correctValue := obs.Value == 1.1681 && obs.TimePeriod == "2025-10-17"
+	correctAttrs := obs.Attributes[AttrDecimals] == "4" && obs.Attributes[AttrObsStatus] == "A"
+
+	if !correctKey || !correctValue || !correctAttrs {
+		t.Errorf("invalid observation: %+v", obs)
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		sample := "KEY,FREQ,CURRENCY,TIME_PERIOD,OBS_VALUE\n" +
+			"EXR.D.USD,D,USD,2025-10-17,1.1681\n" +
+			"EXR.D.JPY,D,JPY,2025-10-17,n/a\n" +
+			"EXR.D.GBP,D,GBP\n" +
+			"EXR.D.CHF,D,CHF,2025-10-17,+Inf\n"
+
+		observations, report, err := ParseCSV([]byte(sample))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctCounts := len(observations) == 1 && report.Parsed == 1 && len(report.Skipped) == 3
+		if !correctCounts {
+			t.Fatalf("got %d observations and report %s", len(observations), report)
+		}
+
+		jpy := report.Skipped[0]
+		correctRecord := jpy.Record == "row 2" && jpy.Currency == "JPY" && jpy.Reason == `invalid value "n/a"`
+		if !correctRecord {
+			t.Errorf("invalid skipped record: %+v", jpy)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		sample := strings.Replace(sampleJSON, `[175.48, 0]`, `["175,48", 0]`, 1)
+
+		observations, report, err := ParseJSON([]byte(sample))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctCounts := len(observations) == 2 && len(report.Skipped) == 1
+		if !correctCounts {
+			t.Fatalf("got %d observations and report %s", len(observations), report)
+		}
+
+		correctCurrency := slices.Equal(report.SkippedCurrencies(), []string{"JPY"})
+		if !correctCurrency {
+			t.Errorf("invalid skipped record: %+v", report.Skipped[0])
+		}
+	})
+
+	t.Run("generic", func(t *testing.T) {
+		sample := strings.Replace(sampleGeneric, `value="1.1681"`, `value="1.1681 EUR"`, 1)
+
+		observations, report, err := ParseGeneric([]byte(sample))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		correctCounts := len(observations) == 0 && len(report.Skipped) == 1
+		if !correctCounts || report.Skipped[0].Currency != "USD" {
+			t.Errorf("got %d observations and report %s", len(observations), report)
+		}
+	})
+}
+
+func TestURL(t *testing.T) {
+	c := NewClient("https://example.org/service/", nil)
+
+	q := Query{
+		Flow:              "EXR",
+		Key:               [][]string{{"D"}, {"USD", "JPY"}, {"EUR"}, nil, {"A"}},
+		StartPeriod:       "2025-10-01",
+		UpdatedAfter:      time.Date(2025, 10, 17, 16, 0, 0, 0, time.UTC),
+		LastNObservations: 1,
+	}
+
+	want := "https://example.org/service/data/EXR/D.USD+JPY.EUR..A?lastNObservations=1&startPeriod=2025-10-01&updatedAfter=2025-10-17T16%3A00%3A00Z"
+	if got := c.URL(q); got != want {
+		t.Errorf("URL() = %s, want %s", got, want)
+	}
+}
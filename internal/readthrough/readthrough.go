@@ -0,0 +1,301 @@
+// Package readthrough answers rate reads from the store and, when the store
+// has nothing for the date, fetches that day from the providers, stores it and
+// answers again. New installs get historical rates without a backfill.
+package readthrough
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/xhos/fxgo/internal/anomaly"
+	"github.com/xhos/fxgo/internal/calendar"
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+const (
+	// DefaultRetryAfter keeps a day that was fetched but still misses the pair,
+	// e.g. not published yet, from being fetched again on every read
+	DefaultRetryAfter = 15 * time.Minute
+	// DefaultFetchTimeout bounds a shared fetch, it outlives the reader that started it
+	DefaultFetchTimeout = 2 * time.Minute
+)
+
+// Reader is a db.Store whose point reads fetch on a miss. Only native rates
+// are stored, so a fetch asks a provider for its whole day and later reads of
+// other pairs on that day are answered from the store. Concurrent misses on
+// the same provider and day share one upstream request.
+type Reader struct {
+	db.Store
+	// providers in priority order
+	providers    []provider.Provider
+	detector     *anomaly.Detector
+	retryAfter   time.Duration
+	fetchTimeout time.Duration
+	now          func() time.Time
+
+	mu       sync.Mutex
+	inflight map[dayKey]*fetch
+	// fetched holds when each day was last fetched without error, for as long
+	// as that keeps it from being fetched again
+	fetched map[dayKey]time.Time
+}
+
+type dayKey struct {
+	provider string
+	// date is empty for the latest publication
+	date string
+}
+
+// fetch is one upstream request, readers that miss while it runs wait for it
+type fetch struct {
+	done chan struct{}
+	err  error
+}
+
+type Option func(*Reader)
+
+func WithRetryAfter(d time.Duration) Option {
+	return func(r *Reader) {
+		r.retryAfter = d
+	}
+}
+
+func WithFetchTimeout(d time.Duration) Option {
+	return func(r *Reader) {
+		r.fetchTimeout = d
+	}
+}
+
+// WithAnomalyConfig sets the thresholds fetched rates are screened with
+func WithAnomalyConfig(config anomaly.Config) Option {
+	return func(r *Reader) {
+		r.detector = anomaly.New(r.Store, config)
+	}
+}
+
+// New reads through to the providers, which are asked in the order given
+func New(store db.Store, providers []provider.Provider, opts ...Option) *Reader {
+	r := &Reader{
+		Store:        store,
+		providers:    providers,
+		detector:     anomaly.New(store, anomaly.DefaultConfig),
+		retryAfter:   DefaultRetryAfter,
+		fetchTimeout: DefaultFetchTimeout,
+		now:          time.Now,
+		inflight:     make(map[dayKey]*fetch),
+		fetched:      make(map[dayKey]time.Time),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// GetRate fetches the date on a miss. It returns nil without an error when no
+// provider publishes the pair that day, and an error only when fetching failed.
+func (r *Reader) GetRate(ctx context.Context, date time.Time, base, target string) (*models.Rate, error) {
+	find := func() (*models.Rate, error) {
+		return r.Store.GetRate(ctx, date, base, target)
+	}
+	return readThrough(ctx, r, date, base, target, find, isFound)
+}
+
+// GetLatestRate fetches the latest publication when the store has none for the pair
+func (r *Reader) GetLatestRate(ctx context.Context, base, target string) (*models.Rate, error) {
+	find := func() (*models.Rate, error) {
+		return r.Store.GetLatestRate(ctx, base, target)
+	}
+	return readThrough(ctx, r, time.Time{}, base, target, find, isFound)
+}
+
+// GetRateAsOf fetches the date when the store can only offer another day's
+// rate. If fetching fails that substitute is still returned, with the failure logged.
+func (r *Reader) GetRateAsOf(ctx context.Context, base, target string, date time.Time, opts db.AsOfOptions) (*db.AsOfResult, error) {
+	find := func() (*db.AsOfResult, error) {
+		return r.Store.GetRateAsOf(ctx, base, target, date, opts)
+	}
+
+	// a historical read asks what the store knew back then, fetching can't change that
+	isHistorical := !opts.KnownAt.IsZero()
+	if isHistorical {
+		return find()
+	}
+
+	isExact := func(result *db.AsOfResult) bool {
+		return result != nil && result.Reason == ""
+	}
+
+	result, err := readThrough(ctx, r, date, base, target, find, isExact)
+	if err != nil {
+		substitute, findErr := find()
+		if findErr != nil || substitute == nil {
+			return nil, err
+		}
+
+		slog.Warn("read-through fetch failed, answering with another day",
+			"pair", base+"/"+target, "date", date.Format("2006-01-02"), "error", err)
+		return substitute, nil
+	}
+
+	return result, nil
+}
+
+// readThrough answers from the store, fetching the date from the providers
+// that can answer the pair until one of them makes the read succeed
+func readThrough[T any](ctx context.Context, r *Reader, date time.Time, base, target string, find func() (T, error), ok func(T) bool) (T, error) {
+	result, err := find()
+	if err != nil || ok(result) {
+		return result, err
+	}
+
+	var errs []error
+	for _, p := range r.candidates(date, base, target) {
+		if err := r.fetchDay(ctx, p, date); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+
+		result, err = find()
+		if err != nil || ok(result) {
+			return result, err
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func isFound(rate *models.Rate) bool {
+	return rate != nil
+}
+
+// candidates lists the providers worth asking, those quoting both currencies
+// first, then those quoting one of them so the store can derive the pair
+// through another source's rates
+func (r *Reader) candidates(date time.Time, base, target string) []provider.Provider {
+	hasDate := !date.IsZero()
+	isFuture := hasDate && date.After(r.now())
+	if isFuture {
+		return nil
+	}
+
+	var both, either []provider.Provider
+	for _, p := range r.providers {
+		closed := hasDate && !calendar.ExpectsData(p.Name(), date)
+		if closed {
+			continue
+		}
+
+		quotesBase, quotesTarget := quotes(p, base), quotes(p, target)
+		switch {
+		case quotesBase && quotesTarget:
+			both = append(both, p)
+		case quotesBase || quotesTarget:
+			either = append(either, p)
+		}
+	}
+
+	return append(both, either...)
+}
+
+func quotes(p provider.Provider, currency string) bool {
+	return p.Base() == currency || slices.Contains(p.SupportedCurrencies(), currency)
+}
+
+// fetchDay runs or joins the fetch of a provider's day. The fetch itself is
+// detached from ctx, so a reader giving up doesn't fail the others waiting on it.
+func (r *Reader) fetchDay(ctx context.Context, p provider.Provider, date time.Time) error {
+	key := dayKey{provider: p.Name()}
+	if !date.IsZero() {
+		key.date = date.Format("2006-01-02")
+	}
+
+	r.mu.Lock()
+	f, running := r.inflight[key]
+
+	fetchedAt, wasFetched := r.fetched[key]
+	recentlyFetched := wasFetched && r.now().Sub(fetchedAt) < r.retryAfter
+	if !running && recentlyFetched {
+		r.mu.Unlock()
+		return nil
+	}
+
+	if !running {
+		f = &fetch{done: make(chan struct{})}
+		r.inflight[key] = f
+
+		go func() {
+			fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.fetchTimeout)
+			defer cancel()
+
+			f.err = r.fetchAndStore(fetchCtx, p, date)
+
+			r.mu.Lock()
+			delete(r.inflight, key)
+			r.pruneFetched()
+			if f.err == nil {
+				r.fetched[key] = r.now()
+			}
+			r.mu.Unlock()
+
+			close(f.done)
+		}()
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pruneFetched forgets days whose retry window has passed, r.mu must be held
+func (r *Reader) pruneFetched() {
+	now := r.now()
+	for key, fetchedAt := range r.fetched {
+		expired := (now.Sub(fetchedAt) >= r.retryAfter)
+		if expired {
+			delete(r.fetched, key)
+		}
+	}
+}
+
+// fetchAndStore asks for every currency the provider quotes against its own
+// base and stores what passes the anomaly checks
+func (r *Reader) fetchAndStore(ctx context.Context, p provider.Provider, date time.Time) error {
+	var targets []string
+	for _, currency := range p.SupportedCurrencies() {
+		if currency != p.Base() {
+			targets = append(targets, currency)
+		}
+	}
+
+	fetched, err := p.FetchRates(ctx, models.RateRequest{Base: p.Base(), Targets: targets, Date: date})
+	notPublished := errors.Is(err, common.ErrNotFound)
+	if notPublished {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.detector.Store(ctx, fetched.Rates); err != nil {
+		return fmt.Errorf("storing fetched rates: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,293 @@
+package readthrough
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/db"
+	"github.com/xhos/fxgo/internal/models"
+	"github.com/xhos/fxgo/internal/provider"
+	"github.com/xhos/fxgo/internal/provider/common"
+)
+
+// a thursday, a business day for every calendar
+var day = time.Date(2025, 10, 16, 0, 0, 0, 0, time.UTC)
+
+type stubProvider struct {
+	rates map[string]float64
+	err   error
+	calls atomic.Int32
+	// release, when set, holds every fetch until it is closed
+	release chan struct{}
+}
+
+func (s *stubProvider) Name() string { return "ECB" }
+func (s *stubProvider) Base() string { return "EUR" }
+
+func (s *stubProvider) SupportedCurrencies() []string {
+	return []string{"USD", "JPY", "GBP"}
+}
+
+func (s *stubProvider) FetchRates(ctx context.Context, req models.RateRequest) (models.RateResult, error) {
+	s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	if s.err != nil {
+		return models.RateResult{}, s.err
+	}
+
+	var rates []models.Rate
+	for _, target := range req.Targets {
+		value, published := s.rates[target]
+		if !published {
+			continue
+		}
+		rates = append(rates, models.Rate{
+			Base: "EUR", Target: target, Value: value, Date: req.Date,
+			Source: "ECB", Fetched: time.Now(), Frequency: models.FrequencyDaily,
+		})
+	}
+	return common.NewRateResult(req.Targets, rates, s.SupportedCurrencies(), common.ParseReport{}), nil
+}
+
+// countingStore counts point reads, a reader's first one is its miss
+type countingStore struct {
+	db.Store
+	reads atomic.Int32
+}
+
+func (s *countingStore) GetRate(ctx context.Context, date time.Time, base, target string) (*models.Rate, error) {
+	s.reads.Add(1)
+	return s.Store.GetRate(ctx, date, base, target)
+}
+
+func newReader(p *stubProvider) (*Reader, *countingStore) {
+	store := &countingStore{Store: db.NewMemory()}
+	return New(store, []provider.Provider{p}), store
+}
+
+func TestGetRate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("miss fetches, stores and answers", func(t *testing.T) {
+		p := &stubProvider{rates: map[string]float64{"USD": 1.17, "JPY": 175.4}}
+		reader, store := newReader(p)
+
+		rate, err := reader.GetRate(ctx, day, "USD", "JPY")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rate == nil {
+			t.Fatal("expected a rate derived from the fetched day")
+		}
+
+		stored, err := store.GetRate(ctx, day, "EUR", "USD")
+		if err != nil || stored == nil {
+			t.Fatalf("fetched day not stored: %v, %v", stored, err)
+		}
+
+		// the day is stored now, so other pairs need no fetch
+		if _, err := reader.GetRate(ctx, day, "EUR", "JPY"); err != nil {
+			t.Fatal(err)
+		}
+		if calls := p.calls.Load(); calls != 1 {
+			t.Errorf("got %d fetches, want 1", calls)
+		}
+	})
+
+	t.Run("unpublished pair is fetched once per retry window", func(t *testing.T) {
+		p := &stubProvider{rates: map[string]float64{"USD": 1.17}}
+		reader, _ := newReader(p)
+
+		for range 3 {
+			rate, err := reader.GetRate(ctx, day, "EUR", "GBP")
+			if err != nil || rate != nil {
+				t.Fatalf("expected no rate and no error, got %v, %v", rate, err)
+			}
+		}
+		if calls := p.calls.Load(); calls != 1 {
+			t.Errorf("got %d fetches, want 1", calls)
+		}
+	})
+
+	t.Run("expired retry windows are forgotten", func(t *testing.T) {
+		p := &stubProvider{rates: map[string]float64{"USD": 1.17}}
+		reader, _ := newReader(p)
+
+		clock := day
+		reader.now = func() time.Time { return clock }
+
+		reader.GetRate(ctx, day, "EUR", "GBP")
+		clock = clock.Add(DefaultRetryAfter)
+		reader.GetRate(ctx, day.AddDate(0, 0, -1), "EUR", "GBP")
+
+		// the first day's window has passed, only the second one is remembered
+		reader.mu.Lock()
+		remembered := len(reader.fetched)
+		reader.mu.Unlock()
+
+		if remembered != 1 {
+			t.Errorf("got %d remembered days, want 1", remembered)
+		}
+	})
+
+	t.Run("no fetch for closed days, future days or unquoted pairs", func(t *testing.T) {
+		p := &stubProvider{rates: map[string]float64{"USD": 1.17}}
+		reader, _ := newReader(p)
+
+		saturday := time.Date(2025, 10, 18, 0, 0, 0, 0, time.UTC)
+		reader.GetRate(ctx, saturday, "EUR", "USD")
+		reader.GetRate(ctx, time.Now().AddDate(0, 0, 7), "EUR", "USD")
+		reader.GetRate(ctx, day, "CAD", "MXN")
+
+		if calls := p.calls.Load(); calls != 0 {
+			t.Errorf("got %d fetches, want none", calls)
+		}
+	})
+
+	t.Run("failed fetch is an error and is retried", func(t *testing.T) {
+		p := &stubProvider{err: common.ErrUpstream}
+		reader, _ := newReader(p)
+
+		_, err := reader.GetRate(ctx, day, "EUR", "USD")
+		if !errors.Is(err, common.ErrUpstream) {
+			t.Errorf("expected the upstream error, got %v", err)
+		}
+
+		reader.GetRate(ctx, day, "EUR", "USD")
+		if calls := p.calls.Load(); calls != 2 {
+			t.Errorf("got %d fetches, want a retry after the failure", calls)
+		}
+	})
+
+	t.Run("not found means not published", func(t *testing.T) {
+		p := &stubProvider{err: common.ErrNotFound}
+		reader, _ := newReader(p)
+
+		rate, err := reader.GetRate(ctx, day, "EUR", "USD")
+		if err != nil || rate != nil {
+			t.Errorf("expected no rate and no error, got %v, %v", rate, err)
+		}
+	})
+}
+
+func TestCoalescing(t *testing.T) {
+	ctx := context.Background()
+	p := &stubProvider{rates: map[string]float64{"USD": 1.17, "JPY": 175.4}, release: make(chan struct{})}
+	reader, store := newReader(p)
+
+	const readers = 10
+	pairs := []string{"USD", "JPY"}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, readers)
+	for i := range readers {
+		wg.Go(func() {
+			rate, err := reader.GetRate(ctx, day, "EUR", pairs[i%2])
+			if err == nil && rate == nil {
+				err = errors.New("no rate")
+			}
+			errs <- err
+		})
+	}
+
+	// hold the fetch until every reader has missed, without coalescing each would fetch
+	waitFor(t, func() bool { return store.reads.Load() >= readers })
+	close(p.release)
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	if calls := p.calls.Load(); calls != 1 {
+		t.Errorf("got %d upstream fetches, want 1", calls)
+	}
+}
+
+func TestCancelledReaderLeavesFetchRunning(t *testing.T) {
+	p := &stubProvider{rates: map[string]float64{"USD": 1.17}, release: make(chan struct{})}
+	reader, store := newReader(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := reader.GetRate(ctx, day, "EUR", "USD")
+		done <- err
+	}()
+
+	waitFor(t, func() bool { return p.calls.Load() == 1 })
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the reader to give up, got %v", err)
+	}
+
+	// a second reader joins the fetch the first one started
+	result := make(chan *models.Rate)
+	go func() {
+		rate, _ := reader.GetRate(context.Background(), day, "EUR", "USD")
+		result <- rate
+	}()
+
+	waitFor(t, func() bool { return store.reads.Load() >= 2 })
+	close(p.release)
+
+	if rate := <-result; rate == nil {
+		t.Error("expected the shared fetch to finish for the second reader")
+	}
+	if calls := p.calls.Load(); calls != 1 {
+		t.Errorf("got %d upstream fetches, want 1", calls)
+	}
+}
+
+func TestGetRateAsOf(t *testing.T) {
+	ctx := context.Background()
+	p := &stubProvider{rates: map[string]float64{"USD": 1.17}}
+	reader, store := newReader(p)
+
+	// the store only has the day before, which GetRateAsOf would substitute
+	dayBefore := day.AddDate(0, 0, -1)
+	store.InsertRate(ctx, models.Rate{Base: "EUR", Target: "USD", Value: 1.16, Date: dayBefore, Source: "ECB", Fetched: time.Now(), Frequency: "D"})
+
+	result, err := reader.GetRateAsOf(ctx, "EUR", "USD", day, db.AsOfOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	correctDay := (result != nil && result.EffectiveDate.Equal(day) && result.Rate.Value == 1.17)
+	if !correctDay {
+		t.Errorf("expected the fetched day instead of a substitute, got %+v", result)
+	}
+
+	t.Run("substitute when fetching fails", func(t *testing.T) {
+		p := &stubProvider{err: common.ErrUpstream}
+		reader, store := newReader(p)
+		store.InsertRate(ctx, models.Rate{Base: "EUR", Target: "USD", Value: 1.16, Date: dayBefore, Source: "ECB", Fetched: time.Now(), Frequency: "D"})
+
+		result, err := reader.GetRateAsOf(ctx, "EUR", "USD", day, db.AsOfOptions{})
+		isSubstitute := (err == nil && result != nil && result.EffectiveDate.Equal(dayBefore))
+		if !isSubstitute {
+			t.Errorf("expected the day before, got %+v, %v", result, err)
+		}
+	})
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,229 @@
+// Package triangulate derives rates between any two currencies by walking
+// a graph of direct rates, possibly mixing sources and pivots.
+package triangulate
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+type Graph struct {
+	edges    map[string][]edge
+	priority map[string]int
+}
+
+type edge struct {
+	to       string
+	value    float64
+	inverted bool
+	rate     models.Rate
+}
+
+type Result struct {
+	Rate models.Rate
+	Path []models.Hop
+}
+
+// NewGraph links every direct rate in both directions. priority lists sources
+// from most to least authoritative, unlisted sources rank after all listed ones.
+func NewGraph(rates []models.Rate, priority []string) *Graph {
+	g := &Graph{
+		edges:    make(map[string][]edge),
+		priority: make(map[string]int, len(priority)),
+	}
+
+	for i, source := range priority {
+		g.priority[source] = i
+	}
+
+	for _, rate := range rates {
+		isUsable := !rate.Calculated && rate.Value > 0 && rate.Base != rate.Target
+		if !isUsable {
+			continue
+		}
+
+		g.edges[rate.Base] = append(g.edges[rate.Base], edge{to: rate.Target, value: rate.Value, rate: rate})
+		g.edges[rate.Target] = append(g.edges[rate.Target], edge{to: rate.Base, value: 1 / rate.Value, inverted: true, rate: rate})
+	}
+
+	return g
+}
+
+// Convert finds the path with the fewest hops, breaking ties by source
+// authority, and multiplies the rates along it
+func (g *Graph) Convert(from, to string) (Result, error) {
+	isSelf := (from == to)
+	if isSelf {
+		return Result{}, fmt.Errorf("base and target are the same (%s)", from)
+	}
+
+	path, ok := g.shortestPath(from, to)
+	if !ok {
+		return Result{}, fmt.Errorf("no path from %s to %s", from, to)
+	}
+
+	return buildResult(from, to, path), nil
+}
+
+// Currencies lists every currency reachable in the graph
+func (g *Graph) Currencies() []string {
+	var currencies []string
+	for currency := range g.edges {
+		currencies = append(currencies, currency)
+	}
+	slices.Sort(currencies)
+	return currencies
+}
+
+// cost orders paths by hop count first, then by the summed edge penalty
+type cost struct {
+	hops    int
+	penalty int
+}
+
+func (c cost) less(other cost) bool {
+	if c.hops != other.hops {
+		return c.hops < other.hops
+	}
+	return c.penalty < other.penalty
+}
+
+// penalty ranks an edge by its source, monthly averages and inversions rank lower
+func (g *Graph) penalty(e edge) int {
+	unlisted := len(g.priority)
+	rank, listed := g.priority[e.rate.Source]
+	if !listed {
+		rank = unlisted
+	}
+
+	p := rank * 4
+	if e.rate.Frequency == models.FrequencyMonthly {
+		p += 2
+	}
+	if e.inverted {
+		p += 1
+	}
+	return p
+}
+
+func (g *Graph) shortestPath(from, to string) ([]edge, bool) {
+	best := map[string]cost{from: {}}
+	prev := make(map[string]edge)
+	prevNode := make(map[string]string)
+
+	queue := &nodeQueue{{currency: from}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(node)
+
+		if bestCost, ok := best[current.currency]; ok && bestCost.less(current.cost) {
+			continue
+		}
+
+		reached := (current.currency == to)
+		if reached {
+			break
+		}
+
+		for _, e := range g.edges[current.currency] {
+			next := cost{hops: current.cost.hops + 1, penalty: current.cost.penalty + g.penalty(e)}
+
+			known, seen := best[e.to]
+			if seen && !next.less(known) {
+				continue
+			}
+
+			best[e.to] = next
+			prev[e.to] = e
+			prevNode[e.to] = current.currency
+			heap.Push(queue, node{currency: e.to, cost: next})
+		}
+	}
+
+	if _, ok := prev[to]; !ok {
+		return nil, false
+	}
+
+	var path []edge
+	for at := to; at != from; at = prevNode[at] {
+		path = append(path, prev[at])
+	}
+	slices.Reverse(path)
+
+	return path, true
+}
+
+func buildResult(from, to string, path []edge) Result {
+	value := 1.0
+	var hops []models.Hop
+	var sources []string
+	var date, fetched time.Time
+	frequency := models.FrequencyDaily
+
+	at := from
+	for _, e := range path {
+		value *= e.value
+
+		hops = append(hops, models.Hop{
+			From:     at,
+			To:       e.to,
+			Value:    e.value,
+			Source:   e.rate.Source,
+			Date:     e.rate.Date,
+			Inverted: e.inverted,
+		})
+		at = e.to
+
+		if !slices.Contains(sources, e.rate.Source) {
+			sources = append(sources, e.rate.Source)
+		}
+		if e.rate.Date.After(date) {
+			date = e.rate.Date
+		}
+		if e.rate.Fetched.After(fetched) {
+			fetched = e.rate.Fetched
+		}
+		if e.rate.Frequency == models.FrequencyMonthly {
+			frequency = models.FrequencyMonthly
+		}
+	}
+
+	isDirect := (len(path) == 1 && !path[0].inverted)
+
+	return Result{
+		Rate: models.Rate{
+			Base:       from,
+			Target:     to,
+			Value:      value,
+			Date:       date,
+			Source:     strings.Join(sources, "+"),
+			Fetched:    fetched,
+			Calculated: !isDirect,
+			Frequency:  frequency,
+		},
+		Path: hops,
+	}
+}
+
+type node struct {
+	currency string
+	cost     cost
+}
+
+type nodeQueue []node
+
+func (q nodeQueue) Len() int           { return len(q) }
+func (q nodeQueue) Less(i, j int) bool { return q[i].cost.less(q[j].cost) }
+func (q nodeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x any)        { *q = append(*q, x.(node)) }
+
+func (q *nodeQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
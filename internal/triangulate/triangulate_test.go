@@ -0,0 +1,73 @@
+package triangulate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xhos/fxgo/internal/models"
+)
+
+func TestConvert(t *testing.T) {
+	day := time.Date(2025, 10, 17, 0, 0, 0, 0, time.UTC)
+
+	rates := []models.Rate{
+		{Base: "PLN", Target: "EUR", Value: 0.235, Date: day, Source: "NBP"},
+		{Base: "EUR", Target: "USD", Value: 1.17, Date: day, Source: "ECB"},
+		{Base: "CAD", Target: "EUR", Value: 0.61, Date: day, Source: "BankOfCanada"},
+		{Base: "CAD", Target: "USD", Value: 0.71, Date: day, Source: "BankOfCanada"},
+		{Base: "USD", Target: "JPY", Value: 150, Date: day, Source: "BIS", Frequency: models.FrequencyMonthly},
+		{Base: "USD", Target: "JPY", Value: 151, Date: day, Source: "IMF"},
+		{Base: "USD", Target: "GBP", Value: 0.75, Date: day, Source: "IMF", Calculated: true},
+	}
+
+	g := NewGraph(rates, []string{"ECB", "BankOfCanada", "IMF", "BIS"})
+
+	t.Run("multi-hop across sources", func(t *testing.T) {
+		result, err := g.Convert("PLN", "CAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := 0.235 / 0.61
+		if math.Abs(result.Rate.Value-want) > 1e-12 {
+			t.Errorf("got %f, want %f", result.Rate.Value, want)
+		}
+
+		if len(result.Path) != 2 || !result.Path[1].Inverted || result.Path[1].Source != "BankOfCanada" {
+			t.Errorf("unexpected path: %+v", result.Path)
+		}
+
+		if !result.Rate.Calculated || result.Rate.Source != "NBP+BankOfCanada" {
+			t.Errorf("unexpected rate: %+v", result.Rate)
+		}
+	})
+
+	t.Run("prefers authoritative source", func(t *testing.T) {
+		result, err := g.Convert("USD", "JPY")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Rate.Value != 151 || result.Rate.Calculated {
+			t.Errorf("expected direct IMF rate, got %+v", result.Rate)
+		}
+	})
+
+	t.Run("inverse", func(t *testing.T) {
+		result, err := g.Convert("USD", "EUR")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if math.Abs(result.Rate.Value-1/1.17) > 1e-12 || result.Path[0].Source != "ECB" {
+			t.Errorf("unexpected inverse: %+v", result)
+		}
+	})
+
+	t.Run("calculated rates are ignored", func(t *testing.T) {
+		if _, err := g.Convert("USD", "GBP"); err == nil {
+			t.Error("expected error, got none")
+		}
+	})
+}